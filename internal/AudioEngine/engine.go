@@ -3,6 +3,8 @@
 //
 // Types:
 //   - PlaybackState: enum for stopped, playing, paused states
+//   - Source: what to play, a file, an archive entry or a range of it,
+//     optionally one of its audio streams
 //   - Engine: interface for audio playback operations
 //
 // Functions: None (interface-only file)
@@ -18,12 +20,38 @@ const (
 	StatePaused
 )
 
+// Source identifies what an engine plays.
+type Source struct {
+	FilePath string
+
+	// AudioStream is the container stream index of the audio stream to
+	// play, as reported by ffprobe. Negative leaves the choice to the
+	// backend, which picks the default stream.
+	AudioStream int
+
+	// Start and Duration restrict playback to a range of the file, e.g. a
+	// track of a CUE sheet. Positions passed to the engine are relative to
+	// Start; a zero Duration plays to the end of the file.
+	Start    float64
+	Duration float64
+
+	// Archive and Entry name a file inside a zip or 7z archive, which is
+	// played instead of FilePath.
+	Archive string
+	Entry   string
+}
+
+// FileSource returns a Source playing the default audio stream of a file.
+func FileSource(filePath string) Source {
+	return Source{FilePath: filePath, AudioStream: -1}
+}
+
 // Engine defines the interface for audio playback backends.
 // Implementations can use FFplay, native audio libraries, etc.
 type Engine interface {
-	// Play starts playing the audio file from the given position with specified volume.
+	// Play starts playing the source from the given position with specified volume.
 	// seekTo is in seconds, volume is 0-100.
-	Play(filePath string, seekTo float64, volume int) error
+	Play(src Source, seekTo float64, volume int) error
 
 	// Stop stops playback and resets position.
 	Stop()
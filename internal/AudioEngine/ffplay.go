@@ -8,6 +8,7 @@
 //   - NewFFplayEngine: creates a new FFplay engine instance
 //   - Play, Stop, Pause, Resume, Seek: playback control methods
 //   - GetState, SetOnComplete: state management methods
+//   - SetFilePath, SetSource: replace the source without playing it
 
 package AudioEngine
 
@@ -16,6 +17,8 @@ import (
 	"os/exec"
 	"strconv"
 	"sync"
+
+	"Player/internal/archive"
 )
 
 // FFplayEngine implements the Engine interface using ffplay.
@@ -24,7 +27,7 @@ type FFplayEngine struct {
 	mu         sync.Mutex
 	state      PlaybackState
 	onComplete func()
-	filePath   string
+	source     Source
 }
 
 // NewFFplayEngine creates a new FFplay-based audio engine.
@@ -34,21 +37,39 @@ func NewFFplayEngine() *FFplayEngine {
 	}
 }
 
-// Play starts playing the audio file.
-func (e *FFplayEngine) Play(filePath string, seekTo float64, volume int) error {
+// Play starts playing the source. An archive entry is extracted to the
+// cache first, outside the lock, so that seeking works as for any file.
+func (e *FFplayEngine) Play(src Source, seekTo float64, volume int) error {
+	input := src.FilePath
+	if src.Archive != "" {
+		local, err := archive.Extract(src.Archive, src.Entry)
+		if err != nil {
+			return err
+		}
+		input = local
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	e.stopInternal()
 
-	e.filePath = filePath
+	e.source = src
 	e.state = StatePlaying
 
 	args := []string{"-nodisp", "-autoexit", "-loglevel", "quiet", "-volume", strconv.Itoa(volume)}
-	if seekTo > 0 {
-		args = append(args, "-ss", fmt.Sprintf("%.2f", seekTo))
+	if src.AudioStream >= 0 {
+		args = append(args, "-ast", strconv.Itoa(src.AudioStream))
+	}
+	if offset := src.Start + seekTo; offset > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.2f", offset))
 	}
-	args = append(args, filePath)
+	// -autoexit at the end of the range lets onComplete advance to the
+	// next track exactly at the boundary.
+	if src.Duration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.2f", src.Duration-seekTo))
+	}
+	args = append(args, input)
 
 	e.cmd = exec.Command("ffplay", args...)
 	cmd := e.cmd
@@ -104,28 +125,29 @@ func (e *FFplayEngine) Pause() {
 // Resume resumes playback from the given position.
 func (e *FFplayEngine) Resume(seekTo float64, volume int) error {
 	e.mu.Lock()
-	if e.state != StatePaused || e.filePath == "" {
+	src := e.source
+	if e.state != StatePaused || src.FilePath == "" {
 		e.mu.Unlock()
 		return nil
 	}
 	e.mu.Unlock()
 
-	return e.Play(e.filePath, seekTo, volume)
+	return e.Play(src, seekTo, volume)
 }
 
 // Seek jumps to the specified position.
 func (e *FFplayEngine) Seek(position float64, volume int) error {
 	e.mu.Lock()
-	filePath := e.filePath
+	src := e.source
 	wasPlaying := e.state == StatePlaying
 	e.mu.Unlock()
 
-	if filePath == "" {
+	if src.FilePath == "" {
 		return nil
 	}
 
 	if wasPlaying {
-		return e.Play(filePath, position, volume)
+		return e.Play(src, position, volume)
 	}
 	return nil
 }
@@ -148,5 +170,13 @@ func (e *FFplayEngine) SetOnComplete(callback func()) {
 func (e *FFplayEngine) SetFilePath(filePath string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.filePath = filePath
+	e.source = FileSource(filePath)
+}
+
+// SetSource replaces what Resume and Seek play without starting playback,
+// e.g. when another audio stream is picked while paused.
+func (e *FFplayEngine) SetSource(src Source) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.source = src
 }
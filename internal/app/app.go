@@ -3,37 +3,74 @@ package app
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"Player/internal/config"
 	"Player/internal/media"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Run starts the Bubble Tea program and loads songs from the provided directory.
-func Run(musicDir string) error {
-	program := tea.NewProgram(initialModel(musicDir), tea.WithAltScreen())
+// Run starts the Bubble Tea program and loads songs from the provided
+// library roots. The first root holds the lyrics folder and names the
+// library for per-library state such as overrides.
+func Run(roots []string, cfg config.Config) error {
+	musicDir := roots[0]
+	opts, err := scanOptions(cfg)
+	if err != nil {
+		return err
+	}
+	opts.Roots = roots
+	if opts.Overrides, err = LoadOverrides(roots); err != nil {
+		return fmt.Errorf("error loading metadata overrides: %w", err)
+	}
+
+	resume, err := loadResumeStore(cfg)
+	if err != nil {
+		return fmt.Errorf("error loading resume positions: %w", err)
+	}
+
+	providers, err := lyricsProviders(cfg, musicDir)
+	if err != nil {
+		return err
+	}
+
+	m := initialModel(roots, opts)
+	m.resume = resume
+	m.lyricsProviders = providers
+	program := tea.NewProgram(m, tea.WithAltScreen())
 
 	go func() {
-		metas, err := media.LoadFromDirectory(musicDir)
+		report, err := media.LoadFromDirectories(roots, opts)
 		if err != nil {
 			fmt.Printf("\nError loading songs: %v\n", err)
 			program.Quit()
 			os.Exit(1)
 		}
 
-		if len(metas) == 0 {
-			fmt.Println("\nNo music files found in the specified directory")
-			fmt.Println("Supported formats: .mp3, .m4a, .flac, .wav, .ogg, .aac, .opus")
+		if len(report.Tracks) == 0 {
+			exts := cfg.Extensions
+			if len(exts) == 0 {
+				exts = media.DefaultExtensions
+			}
+			fmt.Println("\nNo music files found in the specified directories")
+			fmt.Printf("Supported formats: %s\n", strings.Join(exts, ", "))
+			if len(report.Issues) > 0 {
+				fmt.Printf("\n%d file(s) could not be loaded:\n", len(report.Issues))
+				for _, issue := range report.Issues {
+					fmt.Printf("  %s: %v\n", issue.Path, issue.Err)
+				}
+			}
 			program.Quit()
 			os.Exit(1)
 		}
 
-		songs := make([]Song, len(metas))
-		for i, meta := range metas {
-			songs[i] = Song{metadata: meta}
+		songs := make([]Song, len(report.Tracks))
+		for i, meta := range report.Tracks {
+			songs[i] = Song{metadata: meta, audioStream: -1}
 		}
 
-		program.Send(songsLoadedMsg{songs: songs, musicDir: musicDir})
+		program.Send(songsLoadedMsg{songs: songs, musicDir: musicDir, issues: report.Issues})
 	}()
 
 	if _, err := program.Run(); err != nil {
@@ -42,3 +79,24 @@ func Run(musicDir string) error {
 
 	return nil
 }
+
+// scanOptions translates the user config into media scan options.
+func scanOptions(cfg config.Config) (media.Options, error) {
+	opts := media.Options{
+		Exclude:        cfg.Exclude,
+		MinDuration:    cfg.MinDuration,
+		FollowSymlinks: cfg.FollowSymlinks,
+		ScanArchives:   cfg.ScanArchives,
+	}
+	if len(cfg.Extensions) > 0 {
+		opts.Extensions = cfg.Extensions
+	}
+	if cfg.InferFromPath {
+		patterns, err := media.CompilePatterns(cfg.PathPatterns)
+		if err != nil {
+			return opts, err
+		}
+		opts.PathPatterns = patterns
+	}
+	return opts, nil
+}
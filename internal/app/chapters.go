@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// chapterRestartGrace is how far into a chapter "previous chapter" restarts
+// it instead of going back one, as with tracks on a CD player.
+const chapterRestartGrace = 3.0
+
+func (m *model) nextChapter() tea.Cmd {
+	if m.currentSong == nil {
+		return nil
+	}
+	meta := m.currentSong.metadata
+	idx := meta.ChapterAt(m.currentTime)
+	if idx+1 >= len(meta.Chapters) {
+		return nil
+	}
+	return m.seekTo(meta.Chapters[idx+1].Start)
+}
+
+func (m *model) previousChapter() tea.Cmd {
+	if m.currentSong == nil {
+		return nil
+	}
+	meta := m.currentSong.metadata
+	idx := meta.ChapterAt(m.currentTime)
+	if idx < 0 {
+		return nil
+	}
+	if m.currentTime-meta.Chapters[idx].Start < chapterRestartGrace && idx > 0 {
+		idx--
+	}
+	return m.seekTo(meta.Chapters[idx].Start)
+}
+
+// chapterTicks draws a marker under the progress bar at the start of every
+// chapter after the first. width is the width of the whole progress view,
+// percentage included.
+func chapterTicks(meta media.Metadata, width int) string {
+	barWidth := width - len(" 100%")
+	if barWidth <= 0 || meta.Duration <= 0 {
+		return ""
+	}
+
+	line := []rune(strings.Repeat(" ", barWidth))
+	for _, ch := range meta.Chapters[1:] {
+		pos := int(math.Round(float64(barWidth) * ch.Start / meta.Duration))
+		if pos >= 0 && pos < barWidth {
+			line[pos] = '╵'
+		}
+	}
+	return string(line)
+}
+
+// chapterList lets the user jump to a chapter of the current song.
+type chapterList struct {
+	cursor int
+	offset int
+}
+
+func (m *model) openChapterList() {
+	if m.currentSong == nil || len(m.currentSong.metadata.Chapters) == 0 {
+		m.setStatus("The current song has no chapters")
+		return
+	}
+	idx := m.currentSong.metadata.ChapterAt(m.currentTime)
+	if idx < 0 {
+		idx = 0
+	}
+	m.overlay = &chapterList{cursor: idx}
+}
+
+// pageSize is how many chapters fit on screen.
+func (c *chapterList) pageSize(m *model) int {
+	page := m.height - 12
+	if page < 1 {
+		page = 1
+	}
+	return page
+}
+
+func (c *chapterList) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+	if m.currentSong == nil {
+		return nil, nil
+	}
+
+	chapters := m.currentSong.metadata.Chapters
+	switch keyMsg.String() {
+	case "esc", "q", "C":
+		return nil, nil
+	case "down", "j":
+		if c.cursor < len(chapters)-1 {
+			c.cursor++
+		}
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case "pgdown":
+		c.cursor = min(len(chapters)-1, c.cursor+c.pageSize(m))
+	case "pgup":
+		c.cursor = max(0, c.cursor-c.pageSize(m))
+	case "enter":
+		return nil, m.seekTo(chapters[c.cursor].Start)
+	}
+
+	page := c.pageSize(m)
+	if c.cursor < c.offset {
+		c.offset = c.cursor
+	} else if c.cursor >= c.offset+page {
+		c.offset = c.cursor - page + 1
+	}
+	return c, nil
+}
+
+func (c *chapterList) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Chapters") + "\n\n")
+	if m.currentSong == nil {
+		return b.String()
+	}
+
+	meta := m.currentSong.metadata
+	b.WriteString(meta.Title + "\n\n")
+
+	playing := meta.ChapterAt(m.currentTime)
+	end := min(len(meta.Chapters), c.offset+c.pageSize(m))
+	for i := c.offset; i < end; i++ {
+		ch := meta.Chapters[i]
+		marker := "  "
+		if i == playing {
+			marker = "▶ "
+		}
+		line := fmt.Sprintf("%s%s  %s", marker, formatClock(ch.Start), ch.Title)
+		line = ansi.Truncate(line, width, "…")
+		if i == c.cursor {
+			line = overlaySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString(overlayHelpStyle.Render("\n↑↓: choose  enter: jump  esc: close"))
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"image"
+	"strings"
+
+	"Player/internal/artwork"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type artLoadedMsg struct {
+	source string
+}
+
+// loadArtCmd makes the song's cover art available in the cache, extracting
+// and decoding it in the background when it is not cached yet.
+func (m *model) loadArtCmd(song *Song) tea.Cmd {
+	if m.artProtocol == artwork.ProtocolNone {
+		return nil
+	}
+
+	source := media.CoverArtSource(song.metadata)
+	if _, cached := m.artCache.Image(source); cached {
+		return nil
+	}
+
+	cache := m.artCache
+	meta := song.metadata
+	return func() tea.Msg {
+		cache.StoreImage(source, decodeCoverArt(meta))
+		return artLoadedMsg{source: source}
+	}
+}
+
+// decodeCoverArt returns nil when the track has no art or it fails to decode,
+// which the cache records so the lookup is not repeated.
+func decodeCoverArt(meta media.Metadata) image.Image {
+	data, err := media.CoverArt(meta)
+	if err != nil {
+		return nil
+	}
+	img, err := artwork.Decode(data)
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// coverArt returns the current song's art rendered for the Now Playing
+// panel, sized to the available space. It reports false when there is no
+// art or the panel is too small to fit it next to the track info.
+func (m *model) coverArt(panelWidth int) (artwork.Rendered, bool) {
+	if m.currentSong == nil || m.artProtocol == artwork.ProtocolNone {
+		return artwork.Rendered{}, false
+	}
+
+	rows := 0
+	switch {
+	case m.height >= 40:
+		rows = 10
+	case m.height >= 30:
+		rows = 8
+	case m.height >= 24:
+		rows = 6
+	}
+	cols := rows * 2
+	if rows == 0 || panelWidth < cols+30 {
+		return artwork.Rendered{}, false
+	}
+
+	source := media.CoverArtSource(m.currentSong.metadata)
+	return m.artCache.Render(source, m.artProtocol, cols, rows)
+}
+
+// applyArtOverlays appends the protocol-specific escape sequences to the
+// screen lines covered by the art block. firstLine is the 0-based line of
+// the block in view and col its 1-based screen column.
+func applyArtOverlays(view string, art artwork.Rendered, firstLine, col int) string {
+	overlays := art.Overlays(firstLine+1, col)
+	if len(overlays) == 0 {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, overlay := range overlays {
+		if firstLine+i < len(lines) {
+			lines[firstLine+i] += overlay
+		}
+	}
+	return strings.Join(lines, "\n")
+}
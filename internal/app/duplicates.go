@@ -0,0 +1,427 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"Player/internal/config"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// duplicatesFoundMsg carries the result of an audio-hash duplicate search.
+type duplicatesFoundMsg struct {
+	groups []media.DuplicateGroup
+	issues []media.ScanIssue
+}
+
+// findDuplicatesCmd hashes every song's audio in the background.
+func (m *model) findDuplicatesCmd() tea.Cmd {
+	tracks := m.libraryTracks()
+	return func() tea.Msg {
+		groups, issues := media.FindDuplicates(tracks, media.DuplicateOptions{AudioHash: true})
+		return duplicatesFoundMsg{groups: groups, issues: issues}
+	}
+}
+
+// libraryTracks returns the metadata of every song still in the library.
+func (m *model) libraryTracks() []media.Metadata {
+	tracks := make([]media.Metadata, 0, len(m.songs))
+	for _, song := range m.songs {
+		if !song.removed {
+			tracks = append(tracks, song.metadata)
+		}
+	}
+	return tracks
+}
+
+// duplicateRow is one track of a group as listed in the view.
+type duplicateRow struct {
+	group int
+	id    string
+}
+
+// duplicatesView lists groups of copies of the same track, best quality
+// first, and hides or deletes the copies picked.
+type duplicatesView struct {
+	groups   [][]string // song IDs, best quality first
+	byAudio  []bool
+	cursor   int
+	offset   int
+	hashing  bool
+	hashed   bool
+	unhashed int
+	confirm  bool
+}
+
+func (m *model) openDuplicates() {
+	tracks := m.libraryTracks()
+	groups, _ := media.FindDuplicates(tracks, media.DuplicateOptions{})
+	v := &duplicatesView{}
+	v.setGroups(groups)
+	m.overlay = v
+}
+
+func (v *duplicatesView) setGroups(groups []media.DuplicateGroup) {
+	v.groups, v.byAudio = nil, nil
+	for _, g := range groups {
+		ids := make([]string, len(g.Tracks))
+		for i, meta := range g.Tracks {
+			ids[i] = meta.ID()
+		}
+		v.groups = append(v.groups, ids)
+		v.byAudio = append(v.byAudio, g.ByAudio)
+	}
+	v.cursor, v.offset = 0, 0
+}
+
+// rows flattens the groups into the selectable lines of the view.
+func (v *duplicatesView) rows() []duplicateRow {
+	var rows []duplicateRow
+	for g, ids := range v.groups {
+		for _, id := range ids {
+			rows = append(rows, duplicateRow{group: g, id: id})
+		}
+	}
+	return rows
+}
+
+// remove drops a deleted song, and any group left with a single copy.
+func (v *duplicatesView) remove(id string) {
+	groups := v.groups[:0]
+	byAudio := v.byAudio[:0]
+	for g, ids := range v.groups {
+		kept := ids[:0]
+		for _, other := range ids {
+			if other != id {
+				kept = append(kept, other)
+			}
+		}
+		if len(kept) > 1 {
+			groups = append(groups, kept)
+			byAudio = append(byAudio, v.byAudio[g])
+		}
+	}
+	v.groups, v.byAudio = groups, byAudio
+	if rows := len(v.rows()); v.cursor >= rows && v.cursor > 0 {
+		v.cursor = rows - 1
+	}
+}
+
+func (v *duplicatesView) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	switch msg := msg.(type) {
+	case duplicatesFoundMsg:
+		v.hashing, v.hashed = false, true
+		v.unhashed = len(msg.issues)
+		v.setGroups(msg.groups)
+		return v, nil
+	case tea.KeyMsg:
+		return v.handleKey(m, msg)
+	}
+	return v, nil
+}
+
+func (v *duplicatesView) handleKey(m *model, msg tea.KeyMsg) (overlay, tea.Cmd) {
+	rows := v.rows()
+	var row *duplicateRow
+	idx := -1
+	if v.cursor < len(rows) {
+		row = &rows[v.cursor]
+		idx = m.songIndex(row.id)
+	}
+
+	if v.confirm {
+		v.confirm = false
+		if msg.String() != "y" || idx < 0 {
+			return v, nil
+		}
+		cmd, err := m.deleteSong(idx)
+		if err != nil {
+			m.setStatus("Failed to delete: %v", err)
+			return v, nil
+		}
+		v.remove(row.id)
+		return v, cmd
+	}
+
+	switch msg.String() {
+	case "esc", "q", "D":
+		return nil, nil
+	case "down", "j":
+		if v.cursor < len(rows)-1 {
+			v.cursor++
+		}
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "enter":
+		if idx >= 0 {
+			return v, m.playSongCmd(&m.songs[idx])
+		}
+	case "x":
+		if idx < 0 {
+			return v, nil
+		}
+		if m.scanOpts.Overrides == nil {
+			m.setStatus("Overrides are unavailable: no config directory")
+			return v, nil
+		}
+		if err := m.setHidden(idx, !m.songs[idx].metadata.Hidden); err != nil {
+			m.setStatus("Failed to save override: %v", err)
+			return v, nil
+		}
+		return v, m.rebuildPlaylist()
+	case "d", "delete":
+		if idx < 0 {
+			return v, nil
+		}
+		if err := deletable(m.songs[idx].metadata); err != nil {
+			m.setStatus("%v", err)
+			return v, nil
+		}
+		v.confirm = true
+	case "a":
+		if !v.hashing {
+			v.hashing = true
+			m.setStatus("Comparing the decoded audio of %d song(s)…", len(m.songs))
+			return v, m.findDuplicatesCmd()
+		}
+	}
+	return v, nil
+}
+
+// pageSize is how many lines of groups fit on screen.
+func (v *duplicatesView) pageSize(m *model) int {
+	page := m.height - 14
+	if page < 3 {
+		page = 3
+	}
+	return page
+}
+
+func (v *duplicatesView) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Duplicates") + "\n\n")
+
+	switch {
+	case v.hashing:
+		b.WriteString(overlayHelpStyle.Render("Comparing decoded audio; this reads every file…") + "\n\n")
+	case v.hashed:
+		summary := "Matched by tags and decoded audio"
+		if v.unhashed > 0 {
+			summary += fmt.Sprintf("; %d song(s) couldn't be decoded", v.unhashed)
+		}
+		b.WriteString(overlayHelpStyle.Render(summary) + "\n\n")
+	}
+
+	if len(v.groups) == 0 {
+		b.WriteString("No duplicates found.\n")
+		help := "\na: also compare decoded audio  esc: close"
+		if v.hashed || v.hashing {
+			help = "\nesc: close"
+		}
+		b.WriteString(overlayHelpStyle.Render(help))
+		return b.String()
+	}
+
+	// Lay out every line so the cursor can be kept in view.
+	var lines []string
+	cursorLine := 0
+	row := 0
+	for g, ids := range v.groups {
+		if g > 0 {
+			lines = append(lines, "")
+		}
+		header := fmt.Sprintf("%d copies", len(ids))
+		if idx := m.songIndex(ids[0]); idx >= 0 {
+			meta := m.songs[idx].metadata
+			header = fmt.Sprintf("%s - %s (%d copies)", meta.Artist, meta.Title, len(ids))
+		}
+		if v.byAudio[g] {
+			header += " · same audio"
+		}
+		lines = append(lines, ansi.Truncate(header, width, "…"))
+
+		for i, id := range ids {
+			idx := m.songIndex(id)
+			if idx < 0 {
+				continue
+			}
+			meta := m.songs[idx].metadata
+			marker := "  "
+			if i == 0 {
+				marker = "★ "
+			}
+			line := fmt.Sprintf("%s%s  %s", marker, formatQuality(meta), m.libraryPath(meta.ID()))
+			if meta.Hidden {
+				line += "  (hidden)"
+			}
+			line = ansi.Truncate(line, width, "…")
+			if row == v.cursor {
+				line = overlaySelectedStyle.Render(line)
+				cursorLine = len(lines)
+			}
+			lines = append(lines, line)
+			row++
+		}
+	}
+
+	page := v.pageSize(m)
+	if cursorLine < v.offset {
+		v.offset = cursorLine
+	}
+	if cursorLine >= v.offset+page {
+		v.offset = cursorLine - page + 1
+	}
+	end := v.offset + page
+	if end > len(lines) {
+		end = len(lines)
+	}
+	b.WriteString(strings.Join(lines[v.offset:end], "\n") + "\n")
+
+	b.WriteString(overlayHelpStyle.Render(fmt.Sprintf("\n%d group(s); ★ is the best copy", len(v.groups))))
+	if v.confirm {
+		b.WriteString("\n" + overlayErrorStyle.Render("Delete this file from disk? y: delete  any other key: cancel"))
+		return b.String()
+	}
+	help := "\n↑↓: choose  enter: play  x: hide/unhide  d: delete file"
+	if !v.hashed && !v.hashing {
+		help += "\na: also compare decoded audio  esc: close"
+	} else {
+		help += "\nesc: close"
+	}
+	b.WriteString(overlayHelpStyle.Render(help))
+	return b.String()
+}
+
+// formatQuality summarises what a copy of a track is encoded as, e.g.
+// "FLAC 24-bit 96.0 kHz" or "MP3 320 kbps 44.1 kHz".
+func formatQuality(meta media.Metadata) string {
+	parts := []string{strings.ToUpper(meta.Codec)}
+	if meta.Lossless && meta.BitsPerSample > 0 {
+		parts = append(parts, fmt.Sprintf("%d-bit", meta.BitsPerSample))
+	}
+	if !meta.Lossless && meta.BitRate > 0 {
+		parts = append(parts, formatBitrate(meta.BitRate))
+	}
+	if meta.SampleRate > 0 {
+		parts = append(parts, formatSampleRate(meta.SampleRate))
+	}
+	return strings.Join(parts, " ")
+}
+
+// songIndex returns the index in m.songs of the song with the given ID, or
+// -1. Removed songs are not found.
+func (m *model) songIndex(id string) int {
+	for i := range m.songs {
+		if !m.songs[i].removed && m.songs[i].metadata.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// deletable explains why a song's file can't be deleted on its own, or
+// returns nil.
+func deletable(meta media.Metadata) error {
+	switch {
+	case meta.IsCueTrack():
+		return fmt.Errorf("%q is a track of a CUE sheet; hide it instead", meta.Title)
+	case meta.InArchive():
+		return fmt.Errorf("%q is inside an archive; hide it instead", meta.Title)
+	}
+	return nil
+}
+
+// deleteSong removes a song's file from disk and the song from the library.
+// Playback stops first if it is the current song.
+//
+// The song is only marked removed, not taken out of m.songs, so pointers
+// held by pending commands and overlays stay valid.
+func (m *model) deleteSong(idx int) (tea.Cmd, error) {
+	meta := m.songs[idx].metadata
+	if err := deletable(meta); err != nil {
+		return nil, err
+	}
+
+	id := meta.ID()
+	if m.currentSong != nil && m.currentSong.metadata.ID() == id {
+		m.stopPlayback()
+		m.currentSong = nil
+	}
+	if err := os.Remove(meta.FilePath); err != nil {
+		return nil, err
+	}
+	if err := m.resume.forget(id); err != nil {
+		m.setStatus("Failed to save positions: %v", err)
+	}
+
+	m.songs[idx].removed = true
+	m.songs[idx].marked = false
+
+	m.setStatus("Deleted %s", m.libraryPath(meta.FilePath))
+	return m.rebuildPlaylist(), nil
+}
+
+// ReportDuplicates scans the library roots and prints every group of
+// duplicate tracks with the quality of each copy, best first. audioHash
+// also compares decoded audio, which reads every file.
+func ReportDuplicates(w io.Writer, roots []string, cfg config.Config, audioHash bool) error {
+	opts, err := scanOptions(cfg)
+	if err != nil {
+		return err
+	}
+	if opts.Overrides, err = LoadOverrides(roots); err != nil {
+		return fmt.Errorf("error loading metadata overrides: %w", err)
+	}
+
+	report, err := media.LoadFromDirectories(roots, opts)
+	if err != nil {
+		return err
+	}
+
+	dupOpts := media.DuplicateOptions{AudioHash: audioHash}
+	if audioHash {
+		dupOpts.Progress = func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rHashing audio %d/%d", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+	}
+	groups, issues := media.FindDuplicates(report.Tracks, dupOpts)
+
+	fmt.Fprintf(w, "%d group(s) of duplicates among %d tracks\n", len(groups), len(report.Tracks))
+	for _, g := range groups {
+		first := g.Tracks[0]
+		fmt.Fprintf(w, "\n%s - %s", first.Artist, first.Title)
+		if g.ByAudio {
+			fmt.Fprint(w, " (same audio)")
+		}
+		fmt.Fprintln(w)
+		for i, meta := range g.Tracks {
+			marker := " "
+			if i == 0 {
+				marker = "*"
+			}
+			line := fmt.Sprintf("  %s %-28s %s  %s", marker, formatQuality(meta), formatClock(meta.Duration), songLocation(meta))
+			if meta.Hidden {
+				line += "  (hidden)"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(w, "\n%d track(s) could not be decoded for the audio hash:\n", len(issues))
+		for _, issue := range issues {
+			fmt.Fprintf(w, "  %s: %v\n", issue.Path, issue.Err)
+		}
+	}
+	return nil
+}
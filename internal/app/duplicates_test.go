@@ -0,0 +1,56 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHideDuplicateAcrossRoots(t *testing.T) {
+	dir := t.TempDir()
+	roots := []string{filepath.Join(dir, "alice"), filepath.Join(dir, "bob")}
+	overrides, err := media.LoadOverrides(filepath.Join(dir, "overrides.json"), roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := initialModel(roots, media.Options{Overrides: overrides})
+	m.loading = false
+	for _, root := range roots {
+		m.songs = append(m.songs, Song{metadata: media.Metadata{
+			FilePath: filepath.Join(root, "Artist", "Album", "01.flac"),
+			Artist:   "Artist",
+			Title:    "Song",
+			Album:    "Album",
+			Duration: 200,
+		}, audioStream: -1})
+	}
+	m.rebuildPlaylist()
+
+	m.openDuplicates()
+	v := m.overlay.(*duplicatesView)
+	if rows := v.rows(); len(rows) != 2 {
+		t.Fatalf("got %d duplicate rows, want 2", len(rows))
+	}
+	hidden := m.songIndex(v.rows()[0].id)
+	kept := 1 - hidden
+	v.update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+
+	if !m.songs[hidden].metadata.Hidden || m.songs[kept].metadata.Hidden {
+		t.Fatalf("hidden flags = %v, %v", m.songs[0].metadata.Hidden, m.songs[1].metadata.Hidden)
+	}
+	if len(m.playlist) != 1 || m.playlist[0] != kept {
+		t.Errorf("playlist = %v, want only song %d", m.playlist, kept)
+	}
+
+	// A rescan applies the saved overrides; the kept copy must stay visible.
+	if ov, ok := overrides.Lookup(m.songs[kept].metadata); ok && ov.Hidden {
+		t.Error("the kept copy is hidden by its override")
+	}
+	if ov, ok := overrides.Lookup(m.songs[hidden].metadata); !ok || !ov.Hidden {
+		t.Error("the hidden copy has no override hiding it")
+	}
+}
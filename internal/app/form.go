@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// formField is one labelled text input of a form.
+type formField struct {
+	label   string
+	input   textinput.Model
+	initial string
+}
+
+// form is a vertical list of text inputs shared by the editing overlays.
+type form struct {
+	fields []formField
+	focus  int
+}
+
+// formAction is what a key press asked the form's owner to do.
+type formAction int
+
+const (
+	formNone formAction = iota
+	formSubmit
+	formCancel
+)
+
+// addField appends a field prefilled with value. A non-empty placeholder is
+// shown while the field is empty.
+func (f *form) addField(label, value, placeholder string) {
+	input := textinput.New()
+	input.Prompt = ""
+	input.CharLimit = 256
+	input.Placeholder = placeholder
+	input.SetValue(value)
+
+	f.fields = append(f.fields, formField{label: label, input: input, initial: value})
+}
+
+// start focuses the first field.
+func (f *form) start() tea.Cmd {
+	if len(f.fields) == 0 {
+		return nil
+	}
+	f.focus = 0
+	return f.fields[0].input.Focus()
+}
+
+// value returns the trimmed contents of field i.
+func (f *form) value(i int) string {
+	return strings.TrimSpace(f.fields[i].input.Value())
+}
+
+// changed reports whether field i differs from its initial value.
+func (f *form) changed(i int) bool {
+	return f.value(i) != f.fields[i].initial
+}
+
+func (f *form) setFocus(idx int) tea.Cmd {
+	f.fields[f.focus].input.Blur()
+	f.focus = (idx + len(f.fields)) % len(f.fields)
+	return f.fields[f.focus].input.Focus()
+}
+
+// update handles navigation keys and forwards everything else to the
+// focused input.
+func (f *form) update(msg tea.Msg) (formAction, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return formCancel, nil
+		case "tab", "down":
+			return formNone, f.setFocus(f.focus + 1)
+		case "shift+tab", "up":
+			return formNone, f.setFocus(f.focus - 1)
+		case "enter", "ctrl+s":
+			return formSubmit, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	f.fields[f.focus].input, cmd = f.fields[f.focus].input.Update(msg)
+	return formNone, cmd
+}
+
+func (f *form) view(width int) string {
+	labelWidth := 0
+	for _, field := range f.fields {
+		if len(field.label) > labelWidth {
+			labelWidth = len(field.label)
+		}
+	}
+
+	var b strings.Builder
+	for i, field := range f.fields {
+		label := fmt.Sprintf("%-*s", labelWidth+1, field.label+":")
+		if i == f.focus {
+			label = overlaySelectedStyle.Render(label)
+		}
+		field.input.Width = width - labelWidth - 4
+		b.WriteString(label + " " + field.input.View() + "\n")
+	}
+	return b.String()
+}
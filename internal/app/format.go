@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"Player/internal/media"
+)
+
+// formatExtraTags renders the optional tag fields for the Now Playing panel,
+// skipping any that are not set.
+func formatExtraTags(meta media.Metadata) string {
+	var b strings.Builder
+	if meta.AlbumArtist != "" && meta.AlbumArtist != meta.Artist {
+		fmt.Fprintf(&b, "Album Artist: %s\n", meta.AlbumArtist)
+	}
+	if meta.TrackNumber > 0 {
+		track := fmt.Sprintf("%d", meta.TrackNumber)
+		if meta.TrackTotal > 0 {
+			track += fmt.Sprintf("/%d", meta.TrackTotal)
+		}
+		if meta.DiscNumber > 0 {
+			track += fmt.Sprintf("  Disc %d", meta.DiscNumber)
+			if meta.DiscTotal > 0 {
+				track += fmt.Sprintf("/%d", meta.DiscTotal)
+			}
+		}
+		fmt.Fprintf(&b, "Track:  %s\n", track)
+	}
+	if meta.Year > 0 {
+		fmt.Fprintf(&b, "Year:   %d\n", meta.Year)
+	}
+	if meta.Genre != "" {
+		fmt.Fprintf(&b, "Genre:  %s\n", meta.Genre)
+	}
+	if meta.Composer != "" {
+		fmt.Fprintf(&b, "Composer: %s\n", meta.Composer)
+	}
+	return b.String()
+}
+
+// songLocation names where a song lives: its file, plus the track for
+// tracks of a CUE sheet.
+func songLocation(meta media.Metadata) string {
+	if meta.IsCueTrack() {
+		return fmt.Sprintf("%s (track %d of %s)", meta.FilePath, meta.CueTrack, filepath.Base(meta.CueSheet))
+	}
+	return meta.FilePath
+}
+
+// formatAudioInfo renders the technical fields for the audio-info panel.
+func formatAudioInfo(meta media.Metadata) string {
+	lines := []string{
+		fmt.Sprintf("Bitrate:     %s", formatBitrate(meta.BitRate)),
+		fmt.Sprintf("Codec:       %s", formatCodec(meta)),
+		fmt.Sprintf("Sample Rate: %s", formatSampleRate(meta.SampleRate)),
+		fmt.Sprintf("Channels:    %s", formatChannels(meta.Channels, meta.ChannelLayout)),
+	}
+	if meta.BitsPerSample > 0 {
+		lines = append(lines, fmt.Sprintf("Bit Depth:   %d-bit", meta.BitsPerSample))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatBitrate(bps int) string {
+	if bps <= 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d kbps", bps/1000)
+}
+
+func formatSampleRate(hz int) string {
+	if hz <= 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f kHz", float64(hz)/1000.0)
+}
+
+func formatCodec(meta media.Metadata) string {
+	if meta.Codec == "" {
+		return "N/A"
+	}
+	codec := strings.ToUpper(meta.Codec)
+	if meta.Lossless {
+		codec += " (lossless)"
+	}
+	return codec
+}
+
+func formatChannels(channels int, layout string) string {
+	switch {
+	case layout != "":
+		return layout
+	case channels == 1:
+		return "mono"
+	case channels == 2:
+		return "stereo"
+	case channels > 0:
+		return fmt.Sprintf("%d ch", channels)
+	}
+	return "N/A"
+}
+
+// formatAudioStream summarises one audio stream for the stream picker, e.g.
+// "#1 eng · AAC · 5.1 · 384 kbps · Commentary".
+func formatAudioStream(st media.AudioStream) string {
+	parts := []string{fmt.Sprintf("#%d", st.Index)}
+	if st.Language != "" {
+		parts[0] += " " + st.Language
+	}
+	if st.Codec != "" {
+		parts = append(parts, strings.ToUpper(st.Codec))
+	}
+	if channels := formatChannels(st.Channels, st.ChannelLayout); channels != "N/A" {
+		parts = append(parts, channels)
+	}
+	if st.BitRate > 0 {
+		parts = append(parts, formatBitrate(st.BitRate))
+	}
+	if st.Title != "" {
+		parts = append(parts, st.Title)
+	}
+	if st.Default {
+		parts = append(parts, "default")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatClock renders seconds as m:ss, or h:mm:ss for audiobook lengths.
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
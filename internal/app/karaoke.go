@@ -0,0 +1,86 @@
+package app
+
+import (
+	"strings"
+
+	"Player/internal/lyrics"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	lyricCurrentStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("230")).
+				Bold(true)
+
+	// lyricUnsungStyle is the rest of a line with word timing that hasn't
+	// been sung yet.
+	lyricUnsungStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("245"))
+)
+
+// currentLyricIndex returns the index of the line being sung at
+// currentTime, or -1 before the first line.
+func currentLyricIndex(ly *lyrics.Lyrics, currentTime float64) int {
+	if ly == nil || !ly.Loaded {
+		return -1
+	}
+	at := ly.At(currentTime)
+	idx := -1
+	for i, line := range ly.Lines {
+		if line.Time > at {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// renderLyricLine renders the current line. Lines with word timing fill in
+// word by word, and the word being sung fills in letter by letter; other
+// lines are highlighted as a whole.
+func renderLyricLine(ly *lyrics.Lyrics, idx int, currentTime float64) string {
+	line := ly.Lines[idx]
+	if len(line.Words) == 0 {
+		return lyricCurrentStyle.Render(line.Text)
+	}
+	at := ly.At(currentTime)
+
+	// A word without an end lasts until the next word, or the next line.
+	lineEnd := 0.0
+	if idx+1 < len(ly.Lines) {
+		lineEnd = ly.Lines[idx+1].Time
+	}
+
+	var sung, unsung strings.Builder
+	for i, w := range line.Words {
+		end := w.End
+		if end <= 0 {
+			if i+1 < len(line.Words) {
+				end = line.Words[i+1].Start
+			} else {
+				end = lineEnd
+			}
+		}
+
+		switch {
+		case at < w.Start:
+			unsung.WriteString(w.Text)
+		case end <= w.Start || at >= end:
+			sung.WriteString(w.Text)
+		default:
+			runes := []rune(w.Text)
+			n := int(float64(len(runes)) * (at - w.Start) / (end - w.Start))
+			sung.WriteString(string(runes[:n]))
+			unsung.WriteString(string(runes[n:]))
+		}
+	}
+
+	// Trim the line's outer spaces, as Line.Text is.
+	text := strings.TrimLeft(sung.String(), " ")
+	rest := strings.TrimRight(unsung.String(), " ")
+	if text == "" {
+		rest = strings.TrimLeft(rest, " ")
+	}
+	return lyricCurrentStyle.Render(text) + lyricUnsungStyle.Render(rest)
+}
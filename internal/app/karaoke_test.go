@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"Player/internal/lyrics"
+)
+
+func TestCurrentLyricIndexOffset(t *testing.T) {
+	ly := lyrics.Fetched{Synced: "[00:01.00]a\n[00:05.00]b\n[00:09.00]c"}.Lyrics()
+	tests := []struct {
+		offset float64
+		at     float64
+		want   int
+	}{
+		{offset: 0, at: 0.5, want: -1},
+		{offset: 0, at: 5, want: 1},
+		{offset: 2, at: 3, want: 1},
+		{offset: -2, at: 6, want: 0},
+		// Moved before the start and back.
+		{offset: 10, at: 0, want: 2},
+		{offset: 0, at: 1, want: 0},
+	}
+	for _, tt := range tests {
+		ly.AdjustOffset(tt.offset - ly.Offset)
+		if got := currentLyricIndex(&ly, tt.at); got != tt.want {
+			t.Errorf("offset %v at %v: line %d, want %d", tt.offset, tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"Player/internal/config"
+	"Player/internal/lyrics"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lyricsProviders builds the provider chain from the lyricsSources setting.
+func lyricsProviders(cfg config.Config, musicDir string) (lyrics.Chain, error) {
+	sources := cfg.LyricsSources
+	if len(sources) == 0 {
+		sources = config.DefaultLyricsSources
+	}
+
+	var chain lyrics.Chain
+	for i, source := range sources {
+		var provider lyrics.Provider
+		switch source.Type {
+		case "local":
+			provider = lyrics.NewLocal(musicDir)
+		case "sidecar":
+			provider = lyrics.NewSidecar()
+		case "embedded":
+			provider = lyrics.NewEmbedded()
+		case "lrclib", "online":
+			provider = lyrics.NewLRCLIB(source.URL)
+		case "http":
+			fields, err := httpFields(source.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("lyricsSources[%d]: %w", i, err)
+			}
+			provider, err = lyrics.NewHTTP(lyrics.HTTPConfig{
+				Name:      source.Name,
+				SearchURL: source.URL,
+				GetURL:    source.GetURL,
+				Results:   source.Results,
+				Fields:    fields,
+				Headers:   source.Headers,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("lyricsSources[%d]: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("lyricsSources[%d]: unknown type %q (want local, sidecar, embedded, lrclib or http)", i, source.Type)
+		}
+		chain = append(chain, provider)
+	}
+	return chain, nil
+}
+
+// httpFields maps the config's field names onto lyrics.HTTPFields.
+func httpFields(names map[string]string) (lyrics.HTTPFields, error) {
+	var fields lyrics.HTTPFields
+	targets := map[string]*string{
+		"id":           &fields.ID,
+		"artist":       &fields.Artist,
+		"title":        &fields.Title,
+		"album":        &fields.Album,
+		"duration":     &fields.Duration,
+		"synced":       &fields.Synced,
+		"plain":        &fields.Plain,
+		"instrumental": &fields.Instrumental,
+	}
+	for name, path := range names {
+		target, ok := targets[name]
+		if !ok {
+			known := make([]string, 0, len(targets))
+			for k := range targets {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fields, fmt.Errorf("unknown field %q (want %s)", name, strings.Join(known, ", "))
+		}
+		*target = path
+	}
+	return fields, nil
+}
+
+// lyricsQuery describes a song to the lyrics providers. CUE tracks share
+// their file with the rest of the album, so its sidecar and tags are not
+// theirs.
+func lyricsQuery(meta media.Metadata) lyrics.Query {
+	q := lyrics.Query{
+		Artist:   meta.Artist,
+		Title:    meta.Title,
+		Album:    meta.Album,
+		Duration: meta.Duration,
+		Name:     lyricsName(meta),
+	}
+	if !meta.IsCueTrack() {
+		q.Embedded = meta.Lyrics
+		if path, err := media.LocalPath(meta); err == nil {
+			q.FilePath = path
+		}
+	}
+	return q
+}
+
+// loadLyricsCmd asks the provider chain for the song's lyrics. Lyrics
+// found online are saved to the lyrics folder, so the next lookup finds
+// them locally; when that fails they are still shown.
+func (m *model) loadLyricsCmd(song *Song) tea.Cmd {
+	musicDir := m.musicDir
+	chain := m.lyricsProviders
+	return func() tea.Msg {
+		q := lyricsQuery(song.metadata)
+		found, ok := chain.Find(context.Background(), q)
+		if !ok {
+			return lyricsLoadedMsg{song: song, lyrics: &lyrics.Lyrics{Loaded: true}}
+		}
+		ly := found.Lyrics
+		var saveErr error
+		if !found.Fetched.Empty() {
+			saved, err := lyrics.SaveFetched(q.Name, musicDir, found.Fetched, found.Match())
+			if err == nil {
+				ly = saved
+			}
+			saveErr = err
+		}
+		if !ly.Match.Known() {
+			ly.Match = found.Match()
+		}
+		ly.Loaded = true
+		return lyricsLoadedMsg{song: song, lyrics: &ly, saveErr: saveErr}
+	}
+}
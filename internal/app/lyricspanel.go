@@ -0,0 +1,192 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"Player/internal/lyrics"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// lyricsMinHeight and lyricsMaxHeight bound the lyrics panel; it takes
+	// whatever the Now Playing panel leaves within them.
+	lyricsMinHeight = 5
+	lyricsMaxHeight = 15
+
+	// lyricsOffsetStep is how far one press of < or > moves the lyrics,
+	// in seconds.
+	lyricsOffsetStep = 0.1
+)
+
+var (
+	lyricPastStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("239"))
+
+	lyricFutureStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("246"))
+
+	// lyricUnsyncedStyle marks lyrics without timing, which can't follow
+	// playback.
+	lyricUnsyncedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("0")).
+				Background(lipgloss.Color("214")).
+				Padding(0, 1)
+)
+
+// lyricsPanel shows every line of the current song's lyrics with the active
+// line kept in the middle. Scrolling by hand stops it from following
+// playback until it is re-synced.
+type lyricsPanel struct {
+	viewport viewport.Model
+	manual   bool
+
+	// target is the offset that centers the active line, updated on each
+	// render; follow moves towards it.
+	target int
+	songID string
+}
+
+func newLyricsPanel() lyricsPanel {
+	return lyricsPanel{viewport: viewport.New(0, lyricsMinHeight)}
+}
+
+// scroll moves the panel by hand and pauses following.
+func (p *lyricsPanel) scroll(lines int) {
+	p.manual = true
+	if lines < 0 {
+		p.viewport.ScrollUp(-lines)
+	} else {
+		p.viewport.ScrollDown(lines)
+	}
+}
+
+// resync makes the panel follow playback again.
+func (p *lyricsPanel) resync() {
+	p.manual = false
+}
+
+// follow steps towards the active line, one line per tick so the lyrics
+// glide rather than jump. Far-off targets, e.g. after a seek, are reached
+// at once.
+func (p *lyricsPanel) follow() {
+	if p.manual {
+		return
+	}
+	offset := p.viewport.YOffset
+	switch diff := p.target - offset; {
+	case diff > p.viewport.Height || -diff > p.viewport.Height:
+		p.viewport.SetYOffset(p.target)
+	case diff > 0:
+		p.viewport.SetYOffset(offset + 1)
+	case diff < 0:
+		p.viewport.SetYOffset(offset - 1)
+	}
+}
+
+// render lays out the lyrics of song at currentTime in a width × height
+// panel. Past lines are dimmed more than the lines still to come.
+func (p *lyricsPanel) render(song *Song, currentTime float64, width, height int) string {
+	ly := song.lyrics
+	p.reset(song, width, height)
+
+	active := currentLyricIndex(ly, currentTime)
+
+	// Padding above and below lets the first and last lines be centered.
+	pad := height / 2
+	rows := make([]string, 0, len(ly.Lines)+2*pad)
+	for i := 0; i < pad; i++ {
+		rows = append(rows, "")
+	}
+	activeRow, activeRows := pad, 1
+	for i, line := range ly.Lines {
+		var text string
+		switch {
+		case i == active:
+			text = renderLyricLine(ly, i, currentTime)
+		case i < active:
+			text = lyricPastStyle.Render(line.Text)
+		default:
+			text = lyricFutureStyle.Render(line.Text)
+		}
+		wrapped := strings.Split(ansi.Wrap(text, width, ""), "\n")
+		if i == active {
+			activeRow, activeRows = len(rows), len(wrapped)
+		}
+		rows = append(rows, wrapped...)
+	}
+	for i := 0; i < pad; i++ {
+		rows = append(rows, "")
+	}
+
+	p.viewport.SetContent(strings.Join(rows, "\n"))
+	p.target = activeRow + activeRows/2 - height/2
+	if p.target < 0 {
+		p.target = 0
+	}
+	return p.viewport.View()
+}
+
+// reset sizes the viewport and starts a new song at the top, following.
+func (p *lyricsPanel) reset(song *Song, width, height int) {
+	if id := song.metadata.ID(); id != p.songID {
+		p.songID = id
+		p.manual = false
+		p.viewport.SetYOffset(0)
+	}
+	p.viewport.Width = width
+	p.viewport.Height = height
+}
+
+// renderPlain shows unsynced lyrics as a static block that only scrolls by
+// hand.
+func (p *lyricsPanel) renderPlain(song *Song, width, height int) string {
+	p.reset(song, width, height)
+	p.viewport.SetContent(lyricFutureStyle.Render(ansi.Wrap(song.lyrics.Plain, width, "")))
+	// Nothing to follow: keep follow from moving the text.
+	p.target = p.viewport.YOffset
+	return p.viewport.View()
+}
+
+// lyricsHeight is how many rows the lyrics panel gets below the rest of the
+// Now Playing panel.
+func (m *model) lyricsHeight(used int) int {
+	height := m.height - used - 4
+	if height < lyricsMinHeight {
+		return lyricsMinHeight
+	}
+	if height > lyricsMaxHeight {
+		return lyricsMaxHeight
+	}
+	return height
+}
+
+// nudgeLyricsOffset moves the current song's synced lyrics by delta seconds
+// of offset, positive for sooner, and saves the new offset to their LRC
+// file.
+func (m *model) nudgeLyricsOffset(delta float64) {
+	if m.currentSong == nil || m.currentSong.lyrics == nil || len(m.currentSong.lyrics.Lines) == 0 {
+		m.setStatus("No synced lyrics to adjust")
+		return
+	}
+	ly := m.currentSong.lyrics
+	ly.AdjustOffset(delta)
+	if ly.Path == "" {
+		m.setStatus("Lyrics offset %s (not saved: the lyrics are in the audio file's tags)", formatLyricsOffset(ly.Offset))
+		return
+	}
+	if err := lyrics.WriteOffset(ly.Path, ly.Offset); err != nil {
+		m.setStatus("Failed to save lyrics offset: %v", err)
+		return
+	}
+	m.setStatus("Lyrics offset %s", formatLyricsOffset(ly.Offset))
+}
+
+// formatLyricsOffset shows an offset the way it reads in the panel:
+// "+0.3s" shows the lyrics sooner.
+func formatLyricsOffset(offset float64) string {
+	return fmt.Sprintf("%+.1fs", offset)
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"Player/internal/lyrics"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// lyricsPreviewLines is how many lines of the highlighted candidate are
+// shown.
+const lyricsPreviewLines = 4
+
+// lyricsCandidatesMsg carries the results of a picker search.
+type lyricsCandidatesMsg struct {
+	candidates []lyrics.Candidate
+	err        error
+}
+
+// lyricsSavedMsg reports lyrics chosen in the picker being saved.
+type lyricsSavedMsg struct {
+	id     string
+	lyrics lyrics.Lyrics
+	err    error
+}
+
+// lyricsPicker searches the lyrics providers with an editable artist and
+// title and saves the candidate the user picks over the song's lyrics.
+type lyricsPicker struct {
+	song       media.Metadata
+	form       form
+	editing    bool
+	searching  bool
+	saving     bool
+	candidates []lyrics.Candidate
+	cursor     int
+	offset     int
+	err        string
+}
+
+// openLyricsPicker opens the picker for the playing song, or the selected
+// one when nothing plays, and searches with its tags right away.
+func (m *model) openLyricsPicker() tea.Cmd {
+	var meta media.Metadata
+	if m.currentSong != nil {
+		meta = m.currentSong.metadata
+	} else if idx := m.selectedSongIndex(); idx >= 0 {
+		meta = m.songs[idx].metadata
+	} else {
+		return nil
+	}
+
+	picker := &lyricsPicker{song: meta}
+	picker.form.addField("Artist", meta.Artist, "")
+	picker.form.addField("Title", meta.Title, "")
+	m.overlay = picker
+	return picker.search(m)
+}
+
+// search asks every provider for candidates that can be saved, which
+// leaves out the files the song's lyrics were read from.
+func (p *lyricsPicker) search(m *model) tea.Cmd {
+	p.searching = true
+	p.err = ""
+	song := p.song
+	artist, title := p.form.value(0), p.form.value(1)
+	chain := m.lyricsProviders
+	return func() tea.Msg {
+		q := lyricsQuery(song)
+		q.Artist, q.Title = artist, title
+		found, err := chain.Search(context.Background(), q)
+		var candidates []lyrics.Candidate
+		for _, c := range found {
+			if !c.Fetched.Empty() {
+				candidates = append(candidates, c)
+			}
+		}
+		return lyricsCandidatesMsg{candidates: candidates, err: err}
+	}
+}
+
+// saveCmd stores f over the song's lyrics.
+func (p *lyricsPicker) saveCmd(m *model, f lyrics.Fetched, match lyrics.Match) tea.Cmd {
+	p.saving = true
+	p.err = ""
+	id := p.song.ID()
+	name := lyricsName(p.song)
+	musicDir := m.musicDir
+	return func() tea.Msg {
+		ly, err := lyrics.SaveFetched(name, musicDir, f, match)
+		return lyricsSavedMsg{id: id, lyrics: ly, err: err}
+	}
+}
+
+func (p *lyricsPicker) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lyricsCandidatesMsg:
+		p.searching = false
+		p.candidates, p.cursor, p.offset = msg.candidates, 0, 0
+		if msg.err != nil {
+			p.err = msg.err.Error()
+		}
+		return p, nil
+
+	case lyricsSavedMsg:
+		p.saving = false
+		if msg.err != nil {
+			p.err = msg.err.Error()
+			return p, nil
+		}
+		if idx := m.songIndex(msg.id); idx >= 0 {
+			ly := msg.lyrics
+			m.songs[idx].lyrics = &ly
+			if m.currentSong == &m.songs[idx] {
+				m.lyricsLoading = false
+			}
+		}
+		m.lyricsPanel.resync()
+		m.setStatus("Lyrics saved")
+		return nil, nil
+	}
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	if isKey && p.saving {
+		return p, nil
+	}
+
+	if p.editing {
+		action, cmd := p.form.update(msg)
+		switch action {
+		case formCancel:
+			p.editing = false
+			p.form.fields[p.form.focus].input.Blur()
+			return p, nil
+		case formSubmit:
+			p.editing = false
+			p.form.fields[p.form.focus].input.Blur()
+			return p, p.search(m)
+		}
+		return p, cmd
+	}
+
+	if !isKey {
+		return p, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "q", "F":
+		return nil, nil
+	case "/", "e":
+		p.editing = true
+		return p, p.form.start()
+	case "down", "j":
+		if p.cursor < len(p.candidates)-1 {
+			p.cursor++
+		}
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "enter":
+		if p.cursor < len(p.candidates) && !p.searching {
+			c := p.candidates[p.cursor]
+			return p, p.saveCmd(m, c.Fetched, c.Match())
+		}
+	case "i":
+		return p, p.saveCmd(m, lyrics.Fetched{Instrumental: true}, lyrics.Match{})
+	}
+	return p, nil
+}
+
+func (p *lyricsPicker) pageSize(m *model) int {
+	page := m.height - 20 - lyricsPreviewLines
+	if page < 3 {
+		page = 3
+	}
+	return page
+}
+
+func (p *lyricsPicker) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Find Lyrics") + "\n\n")
+	b.WriteString(overlayHelpStyle.Render(ansi.Truncate(songLocation(p.song), width, "…")) + "\n\n")
+	b.WriteString(p.form.view(width) + "\n")
+
+	switch {
+	case p.searching:
+		b.WriteString("Searching...\n")
+	case p.saving:
+		b.WriteString("Saving lyrics...\n")
+	case len(p.candidates) == 0:
+		b.WriteString("No lyrics found. Edit the artist or title and search again.\n")
+	default:
+		page := p.pageSize(m)
+		if p.cursor < p.offset {
+			p.offset = p.cursor
+		}
+		if p.cursor >= p.offset+page {
+			p.offset = p.cursor - page + 1
+		}
+		end := min(p.offset+page, len(p.candidates))
+		for i := p.offset; i < end; i++ {
+			line := ansi.Truncate(formatCandidate(p.candidates[i], p.song.Duration), width, "…")
+			if i == p.cursor {
+				line = overlaySelectedStyle.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+		if len(p.candidates) > page {
+			b.WriteString(overlayHelpStyle.Render(fmt.Sprintf("%d of %d", p.cursor+1, len(p.candidates))) + "\n")
+		}
+		b.WriteString("\n" + candidatePreview(p.candidates[p.cursor], width))
+	}
+	if p.err != "" {
+		b.WriteString(overlayErrorStyle.Render(p.err) + "\n")
+	}
+
+	help := "\n↑↓: choose  enter: use these lyrics  i: mark instrumental / no lyrics\n/: edit search  esc: close"
+	if p.editing {
+		help = "\ntab/↑↓: move  enter: search  esc: back to results"
+	}
+	b.WriteString(overlayHelpStyle.Render(help))
+	return b.String()
+}
+
+// formatCandidate summarises a candidate on one line: its match score,
+// kind, artist and title, album, length and provider. Lengths far from the
+// song's are flagged.
+func formatCandidate(c lyrics.Candidate, songDuration float64) string {
+	kind := "synced"
+	switch {
+	case c.Lyrics.Instrumental && len(c.Lyrics.Lines) == 0 && c.Lyrics.Plain == "":
+		kind = "instr."
+	case len(c.Lyrics.Lines) == 0:
+		kind = "plain"
+	}
+	parts := []string{fmt.Sprintf("%3.0f%% %-6s %s - %s", c.Confidence*100, kind, c.Artist, c.Title)}
+	if c.Album != "" {
+		parts = append(parts, c.Album)
+	}
+	if c.Duration > 0 {
+		length := formatClock(c.Duration)
+		if songDuration > 0 && (c.Duration-songDuration > lyrics.DurationTolerance || songDuration-c.Duration > lyrics.DurationTolerance) {
+			length += "!"
+		}
+		parts = append(parts, length)
+	}
+	parts = append(parts, c.Provider)
+	return strings.Join(parts, " · ")
+}
+
+// candidatePreview shows the first lines of a candidate's lyrics.
+func candidatePreview(c lyrics.Candidate, width int) string {
+	var lines []string
+	switch {
+	case len(c.Lyrics.Lines) > 0:
+		for _, line := range c.Lyrics.Lines {
+			if line.Text != "" {
+				lines = append(lines, fmt.Sprintf("[%s] %s", formatClock(c.Lyrics.Position(line.Time)), line.Text))
+			}
+			if len(lines) == lyricsPreviewLines {
+				break
+			}
+		}
+	case c.Lyrics.Plain != "":
+		for _, line := range strings.Split(c.Lyrics.Plain, "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+			if len(lines) == lyricsPreviewLines {
+				break
+			}
+		}
+	default:
+		lines = append(lines, "♪ Instrumental")
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(lyricFutureStyle.Render(ansi.Truncate("  "+line, width, "…")) + "\n")
+	}
+	return b.String()
+}
@@ -3,11 +3,14 @@ package app
 import (
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"Player/internal/AudioEngine"
+	"Player/internal/artwork"
 	"Player/internal/lyrics"
 	"Player/internal/media"
 
@@ -21,11 +24,73 @@ import (
 type Song struct {
 	metadata media.Metadata
 	lyrics   *lyrics.Lyrics
+	marked   bool
+
+	// audioStream is the ffprobe index of the audio stream picked in the
+	// song info view, or -1 for the file's default.
+	audioStream int
+
+	// local is the cached extract an archive song plays from, once
+	// extracted.
+	local string
+
+	// removed marks a song deleted from the library. It stays in m.songs so
+	// pointers to it remain valid, but is left out of the playlist.
+	removed bool
+}
+
+// source returns what the engine should play for the song.
+func (s *Song) source() AudioEngine.Source {
+	src := AudioEngine.FileSource(s.metadata.FilePath)
+	src.AudioStream = s.audioStream
+	if s.metadata.IsCueTrack() {
+		src.Start = s.metadata.Start
+		src.Duration = s.metadata.Duration
+	}
+	if s.metadata.InArchive() {
+		if s.local != "" {
+			src.FilePath = s.local
+		} else {
+			src.Archive = s.metadata.Archive
+			src.Entry = s.metadata.ArchiveEntry
+		}
+	}
+	return src
+}
+
+// playingMetadata returns the song's metadata with the technical fields of
+// the selected audio stream.
+func (s *Song) playingMetadata() media.Metadata {
+	if s.audioStream < 0 {
+		return s.metadata
+	}
+	return s.metadata.ForStream(s.audioStream)
+}
+
+func (s Song) Title() string {
+	if s.marked {
+		return "● " + s.metadata.Title
+	}
+	return s.metadata.Title
 }
 
-func (s Song) Title() string       { return s.metadata.Title }
-func (s Song) Description() string { return s.metadata.Artist }
-func (s Song) FilterValue() string { return s.metadata.Title }
+func (s Song) Description() string {
+	if s.metadata.Hidden {
+		return s.metadata.Artist + " · hidden"
+	}
+	return s.metadata.Artist
+}
+
+// FilterValue joins the searchable tag fields so list filtering matches on
+// more than the title.
+func (s Song) FilterValue() string {
+	meta := s.metadata
+	fields := []string{meta.Title, meta.Artist, meta.Album, meta.AlbumArtist, meta.Composer, meta.Genre, meta.Codec}
+	if meta.Year > 0 {
+		fields = append(fields, fmt.Sprintf("%d", meta.Year))
+	}
+	return strings.Join(fields, " ")
+}
 
 type playerState int
 
@@ -35,48 +100,102 @@ const (
 	statePaused
 )
 
+// statusTimeout is how long a status message stays on screen.
+const statusTimeout = 5 * time.Second
+
 type tickMsg time.Time
 type loadingTickMsg time.Time
 type songsLoadedMsg struct {
 	songs    []Song
 	musicDir string
+	issues   []media.ScanIssue
 }
 type lyricsLoadedMsg struct {
 	song   *Song
 	lyrics *lyrics.Lyrics
+
+	// saveErr reports lyrics found online that could not be saved to the
+	// lyrics folder; they are shown all the same.
+	saveErr error
+}
+
+// archiveExtractedMsg reports an archive song's entry extracted to the
+// cache, ready to play from start. duration is the entry's length when the
+// scan couldn't find it, or zero.
+type archiveExtractedMsg struct {
+	song     *Song
+	start    float64
+	path     string
+	duration float64
+	err      error
 }
 
 type model struct {
-	songs          []Song
-	list           list.Model
-	progress       progress.Model
-	state          playerState
-	currentSong    *Song
-	currentTime    float64
-	engine         *AudioEngine.FFplayEngine
-	mu             sync.Mutex
-	width          int
-	height         int
-	lastUpdateTime time.Time
-	shuffle        bool
-	playHistory    []int
-	lyricsLoading  bool
-	loading        bool
-	loadingDots    int
-	seeking        bool
-	musicDir       string
+	songs           []Song
+	playlist        []int
+	showHidden      bool
+	list            list.Model
+	progress        progress.Model
+	state           playerState
+	currentSong     *Song
+	currentTime     float64
+	engine          *AudioEngine.FFplayEngine
+	mu              sync.Mutex
+	width           int
+	height          int
+	lastUpdateTime  time.Time
+	shuffle         bool
+	playHistory     []int
+	lyricsLoading   bool
+	extracting      *Song
+	loading         bool
+	loadingDots     int
+	seeking         bool
+	musicDir        string
+	roots           []string
+	artCache        *artwork.Cache
+	artProtocol     artwork.Protocol
+	scanOpts        media.Options
+	scanIssues      []media.ScanIssue
+	resume          *resumeStore
+	lyricsPanel     lyricsPanel
+	lyricsProviders lyrics.Chain
+	overlay         overlay
+	status          string
+	statusTime      time.Time
 }
 
 type keyMap struct {
-	Play     key.Binding
-	Pause    key.Binding
-	Stop     key.Binding
-	Next     key.Binding
-	Previous key.Binding
-	Forward  key.Binding
-	Backward key.Binding
-	Shuffle  key.Binding
-	Quit     key.Binding
+	Play         key.Binding
+	Pause        key.Binding
+	Stop         key.Binding
+	Next         key.Binding
+	Previous     key.Binding
+	Forward      key.Binding
+	Backward     key.Binding
+	Shuffle      key.Binding
+	Mark         key.Binding
+	ClearMark    key.Binding
+	EditTags     key.Binding
+	Override     key.Binding
+	Hide         key.Binding
+	ShowHidden   key.Binding
+	ScanIssues   key.Binding
+	SongInfo     key.Binding
+	PlayStart    key.Binding
+	InProgress   key.Binding
+	NextChap     key.Binding
+	PrevChap     key.Binding
+	Chapters     key.Binding
+	Duplicates   key.Binding
+	LyricsUp     key.Binding
+	LyricsDown   key.Binding
+	LyricsSync   key.Binding
+	FindLyrics   key.Binding
+	LyricsSooner key.Binding
+	LyricsLater  key.Binding
+	SyncLyrics   key.Binding
+	Quit         key.Binding
 }
 
 var keys = keyMap{
@@ -112,6 +231,90 @@ var keys = keyMap{
 		key.WithKeys("h"),
 		key.WithHelp("h", "shuffle"),
 	),
+	Mark: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "mark for batch edit"),
+	),
+	ClearMark: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "clear marks"),
+	),
+	EditTags: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit tags"),
+	),
+	Override: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "override metadata"),
+	),
+	Hide: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "hide/unhide song"),
+	),
+	ShowHidden: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "show hidden songs"),
+	),
+	ScanIssues: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "scan issues"),
+	),
+	SongInfo: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "song info and audio streams"),
+	),
+	PlayStart: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "play from the start"),
+	),
+	InProgress: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "continue listening"),
+	),
+	NextChap: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next chapter"),
+	),
+	PrevChap: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous chapter"),
+	),
+	Chapters: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "chapter list"),
+	),
+	Duplicates: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "find duplicates"),
+	),
+	LyricsUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "scroll lyrics up"),
+	),
+	LyricsDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "scroll lyrics down"),
+	),
+	LyricsSync: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "follow lyrics again"),
+	),
+	FindLyrics: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "find lyrics"),
+	),
+	LyricsSooner: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "show lyrics 0.1s sooner"),
+	),
+	LyricsLater: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "show lyrics 0.1s later"),
+	),
+	SyncLyrics: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "sync plain lyrics"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
@@ -119,7 +322,7 @@ var keys = keyMap{
 	// add vol up(c) and down(v)
 }
 
-func initialModel(musicDir string) *model {
+func initialModel(roots []string, opts media.Options) *model {
 	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "Playlist"
 	l.SetShowStatusBar(false)
@@ -145,7 +348,12 @@ func initialModel(musicDir string) *model {
 		lyricsLoading:  false,
 		loading:        true,
 		loadingDots:    0,
-		musicDir:       musicDir,
+		musicDir:       roots[0],
+		roots:          roots,
+		artCache:       artwork.NewCache(),
+		artProtocol:    artwork.DetectProtocol(),
+		scanOpts:       opts,
+		lyricsPanel:    newLyricsPanel(),
 	}
 }
 
@@ -184,17 +392,17 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case songsLoadedMsg:
 		m.songs = msg.songs
 		m.musicDir = msg.musicDir
-		items := make([]list.Item, len(m.songs))
-		for i, song := range m.songs {
-			items[i] = song
-		}
-		m.list.SetItems(items)
+		m.scanIssues = msg.issues
+		cmd := m.rebuildPlaylist()
 		m.loading = false
+		if len(m.scanIssues) > 0 {
+			m.setStatus("Skipped %d file(s) while scanning (i: details)", len(m.scanIssues))
+		}
 
-		if len(m.songs) > 0 {
-			return m, m.playSongCmd(&m.songs[0])
+		if len(m.playlist) > 0 {
+			return m, tea.Batch(cmd, m.playSongCmd(&m.songs[m.playlist[0]]))
 		}
-		return m, tickCmd()
+		return m, tea.Batch(cmd, tickCmd())
 
 	case loadingTickMsg:
 		if m.loading {
@@ -203,13 +411,42 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tickCmd()
 
+	case tagsWrittenMsg:
+		return m, m.applyTagResults(msg)
+
+	case overrideAppliedMsg:
+		return m, m.applyOverrideResult(msg)
+
+	case artLoadedMsg:
+		// The view picks the art up from the cache; re-rendering is enough.
+		return m, nil
+
 	case lyricsLoadedMsg:
-		if m.currentSong != nil && m.currentSong.metadata.FilePath == msg.song.metadata.FilePath {
+		if m.currentSong != nil && m.currentSong.metadata.ID() == msg.song.metadata.ID() {
 			m.currentSong.lyrics = msg.lyrics
 			m.lyricsLoading = false
 		}
+		if msg.saveErr != nil {
+			m.setStatus("Failed to save lyrics: %v", msg.saveErr)
+		}
 		return m, nil
 
+	case archiveExtractedMsg:
+		if m.extracting != msg.song {
+			// Another song was played or playback stopped meanwhile.
+			return m, nil
+		}
+		m.extracting = nil
+		if msg.err != nil {
+			m.setStatus("Failed to extract %s: %v", msg.song.metadata.Title, msg.err)
+			return m, nil
+		}
+		msg.song.local = msg.path
+		if msg.duration > 0 {
+			msg.song.metadata.Duration = msg.duration
+		}
+		return m, m.playSongFromCmd(msg.song, msg.start)
+
 	case tea.KeyMsg:
 		if m.loading {
 			if key.Matches(msg, keys.Quit) {
@@ -218,6 +455,14 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Overlays take every key so their text inputs can receive "q" and
+		// friends; only ctrl+c still quits.
+		if m.overlay != nil && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.overlay, cmd = m.overlay.update(m, msg)
+			return m, cmd
+		}
+
 		if key.Matches(msg, keys.Quit) {
 			m.stopPlayback()
 			return m, tea.Quit
@@ -237,7 +482,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					selectedSong := selectedItem.(Song)
 					// Find the song in the main list to ensure we have the correct pointer/reference
 					for i := range m.songs {
-						if m.songs[i].metadata.FilePath == selectedSong.metadata.FilePath {
+						if m.songs[i].metadata.ID() == selectedSong.metadata.ID() {
 							return m, m.playSongCmd(&m.songs[i])
 						}
 					}
@@ -255,7 +500,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if selectedItem != nil {
 					selectedSong := selectedItem.(Song)
 					for i := range m.songs {
-						if m.songs[i].metadata.FilePath == selectedSong.metadata.FilePath {
+						if m.songs[i].metadata.ID() == selectedSong.metadata.ID() {
 							return m, m.playSongCmd(&m.songs[i])
 						}
 					}
@@ -275,13 +520,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, keys.Forward):
 			if !m.seeking {
-				m.seek(5)
+				return m, m.seek(5)
 			}
 			return m, nil
 
 		case key.Matches(msg, keys.Backward):
 			if !m.seeking {
-				m.seek(-5)
+				return m, m.seek(-5)
 			}
 			return m, nil
 
@@ -289,9 +534,99 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.shuffle = !m.shuffle
 			m.playHistory = make([]int, 0)
 			return m, nil
+
+		case key.Matches(msg, keys.Mark):
+			if idx := m.selectedSongIndex(); idx >= 0 {
+				m.songs[idx].marked = !m.songs[idx].marked
+				cmd := m.refreshSong(idx)
+				m.list.CursorDown()
+				return m, cmd
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.ClearMark):
+			var cmds []tea.Cmd
+			for i := range m.songs {
+				if m.songs[i].marked {
+					m.songs[i].marked = false
+					cmds = append(cmds, m.refreshSong(i))
+				}
+			}
+			return m, tea.Batch(cmds...)
+
+		case key.Matches(msg, keys.EditTags):
+			return m, m.openTagEditor()
+
+		case key.Matches(msg, keys.Override):
+			return m, m.openOverrideEditor()
+
+		case key.Matches(msg, keys.Hide):
+			return m, m.toggleHidden()
+
+		case key.Matches(msg, keys.ShowHidden):
+			return m, m.toggleShowHidden()
+
+		case key.Matches(msg, keys.ScanIssues):
+			m.overlay = &scanIssuesView{}
+			return m, nil
+
+		case key.Matches(msg, keys.SongInfo):
+			m.openSongInfo()
+			return m, nil
+
+		case key.Matches(msg, keys.PlayStart):
+			if idx := m.selectedSongIndex(); idx >= 0 {
+				return m, m.playSongFromCmd(&m.songs[idx], 0)
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.InProgress):
+			m.openInProgress()
+			return m, nil
+
+		case key.Matches(msg, keys.NextChap):
+			return m, m.nextChapter()
+
+		case key.Matches(msg, keys.PrevChap):
+			return m, m.previousChapter()
+
+		case key.Matches(msg, keys.Chapters):
+			m.openChapterList()
+			return m, nil
+
+		case key.Matches(msg, keys.Duplicates):
+			m.openDuplicates()
+			return m, nil
+
+		case key.Matches(msg, keys.LyricsUp):
+			m.lyricsPanel.scroll(-1)
+			return m, nil
+
+		case key.Matches(msg, keys.LyricsDown):
+			m.lyricsPanel.scroll(1)
+			return m, nil
+
+		case key.Matches(msg, keys.LyricsSync):
+			m.lyricsPanel.resync()
+			return m, nil
+
+		case key.Matches(msg, keys.FindLyrics):
+			return m, m.openLyricsPicker()
+
+		case key.Matches(msg, keys.LyricsSooner):
+			m.nudgeLyricsOffset(lyricsOffsetStep)
+			return m, nil
+
+		case key.Matches(msg, keys.LyricsLater):
+			m.nudgeLyricsOffset(-lyricsOffsetStep)
+			return m, nil
+
+		case key.Matches(msg, keys.SyncLyrics):
+			return m, m.openSyncEditor()
 		}
 
 	case tickMsg:
+		m.lyricsPanel.follow()
 		if m.state == statePlaying && m.currentSong != nil {
 			now := time.Now()
 			elapsed := now.Sub(m.lastUpdateTime).Seconds()
@@ -301,13 +636,23 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.lastUpdateTime = now
 
-			if m.currentTime >= m.currentSong.metadata.Duration {
+			// An unknown duration leaves the end to the engine.
+			if d := m.currentSong.metadata.Duration; d > 0 && m.currentTime >= d {
 				return m, tea.Batch(m.playNextCmd(), tickCmd())
 			}
+			if m.resume != nil && time.Since(m.resume.lastSave) >= resumeSaveInterval {
+				m.saveCurrentPosition()
+			}
 		}
 		return m, tickCmd()
 	}
 
+	if m.overlay != nil {
+		var cmd tea.Cmd
+		m.overlay, cmd = m.overlay.update(m, msg)
+		return m, cmd
+	}
+
 	if !m.loading {
 		var cmd tea.Cmd
 		m.list, cmd = m.list.Update(msg)
@@ -317,28 +662,66 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// setStatus shows a short message under the controls.
+func (m *model) setStatus(format string, args ...interface{}) {
+	m.status = fmt.Sprintf(format, args...)
+	m.statusTime = time.Now()
+}
+
 func (m *model) playSongCmd(song *Song) tea.Cmd {
-	m.playSong(song)
+	start := m.resume.position(song)
+	if start > 0 {
+		m.setStatus("Resuming at %s (P plays from the start)", formatClock(start))
+	}
+	return m.playSongFromCmd(song, start)
+}
 
+// playSongFromCmd plays song from start seconds, ignoring any saved
+// position. An archive song is extracted to the cache in the background
+// first and plays once archiveExtractedMsg arrives.
+func (m *model) playSongFromCmd(song *Song, start float64) tea.Cmd {
+	if song != nil && song.metadata.InArchive() && !extractedCopyExists(song) {
+		m.extracting = song
+		m.setStatus("Extracting %s…", song.metadata.Title)
+		meta := song.metadata
+		return func() tea.Msg {
+			path, err := media.LocalPath(meta)
+			msg := archiveExtractedMsg{song: song, start: start, path: path, err: err}
+			if err == nil && meta.Duration <= 0 {
+				msg.duration, _ = media.ProbeDuration(path)
+			}
+			return msg
+		}
+	}
+
+	m.playSong(song, start)
+
+	cmds := []tea.Cmd{m.loadArtCmd(song)}
 	if song.lyrics == nil && !m.lyricsLoading {
 		m.lyricsLoading = true
-		return loadLyricsAsync(song, m.musicDir)
+		cmds = append(cmds, m.loadLyricsCmd(song))
 	}
 
-	return nil
+	return tea.Batch(cmds...)
 }
 
-func (m *model) playSong(song *Song) {
+func (m *model) playSong(song *Song, start float64) {
 	if song == nil {
 		return
 	}
 
+	// A stopped song was saved by stopPlayback and has its time reset.
+	if m.currentTime > 0 {
+		m.saveCurrentPosition()
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.engine.Stop()
+	m.extracting = nil
 	m.currentSong = song
-	m.currentTime = 0
+	m.currentTime = start
 	m.lastUpdateTime = time.Now()
 	m.state = statePlaying
 	m.seeking = false
@@ -352,17 +735,33 @@ func (m *model) playSong(song *Song) {
 		m.mu.Unlock()
 	})
 
-	m.engine.Play(song.metadata.FilePath, 0, 100)
+	if err := m.engine.Play(song.source(), start, 100); err != nil {
+		m.state = stateStopped
+		m.setStatus("Failed to play %s: %v", song.metadata.Title, err)
+	}
+}
+
+// extractedCopyExists reports whether the archive song's cached extract is
+// still on disk; the cache may have dropped it since.
+func extractedCopyExists(song *Song) bool {
+	if song.local == "" {
+		return false
+	}
+	_, err := os.Stat(song.local)
+	return err == nil
 }
 
 func (m *model) stopPlayback() {
+	m.saveCurrentPosition()
 	m.engine.Stop()
+	m.extracting = nil
 	m.state = stateStopped
 	m.currentTime = 0
 	m.seeking = false
 }
 
 func (m *model) pausePlayback() {
+	m.saveCurrentPosition()
 	m.engine.Pause()
 	m.state = statePaused
 }
@@ -385,9 +784,19 @@ func (m *model) resumePlayback() {
 	}
 }
 
-func (m *model) seek(seconds float64) {
+func (m *model) seek(seconds float64) tea.Cmd {
+	return m.seekTo(m.currentTime + seconds)
+}
+
+// seekTo jumps to newTime seconds into the current song. Seeking past the
+// end moves on to the next song, which the returned Cmd goes on to load.
+func (m *model) seekTo(newTime float64) tea.Cmd {
 	if m.currentSong == nil || m.seeking {
-		return
+		return nil
+	}
+	if d := m.currentSong.metadata.Duration; d > 0 && newTime >= d {
+		// Before taking the lock, which playing the next song needs.
+		return m.playNextCmd()
 	}
 
 	m.mu.Lock()
@@ -400,15 +809,9 @@ func (m *model) seek(seconds float64) {
 		})
 	}()
 
-	newTime := m.currentTime + seconds
 	if newTime < 0 {
 		newTime = 0
 	}
-	if newTime >= m.currentSong.metadata.Duration {
-		m.seeking = false
-		m.playNextCmd()
-		return
-	}
 
 	wasPlaying := m.state == statePlaying
 
@@ -428,22 +831,23 @@ func (m *model) seek(seconds float64) {
 
 		m.engine.Seek(m.currentTime, 100)
 	}
+	return nil
 }
 
 func (m *model) playNextCmd() tea.Cmd {
-	if len(m.songs) == 0 {
+	if len(m.playlist) == 0 {
 		return nil
 	}
 
 	var nextIdx int
 
 	if m.shuffle {
-		if len(m.playHistory) >= len(m.songs) {
+		if len(m.playHistory) >= len(m.playlist) {
 			m.playHistory = make([]int, 0)
 		}
 
 		unplayed := make([]int, 0)
-		for i := 0; i < len(m.songs); i++ {
+		for i := 0; i < len(m.playlist); i++ {
 			played := false
 			for _, h := range m.playHistory {
 				if h == i {
@@ -459,21 +863,21 @@ func (m *model) playNextCmd() tea.Cmd {
 		if len(unplayed) > 0 {
 			nextIdx = unplayed[rand.Intn(len(unplayed))]
 		} else {
-			nextIdx = rand.Intn(len(m.songs))
+			nextIdx = rand.Intn(len(m.playlist))
 		}
 
 		m.playHistory = append(m.playHistory, nextIdx)
 	} else {
 		currentIdx := m.list.Index()
-		nextIdx = (currentIdx + 1) % len(m.songs)
+		nextIdx = (currentIdx + 1) % len(m.playlist)
 	}
 
 	m.list.Select(nextIdx)
-	return m.playSongCmd(&m.songs[nextIdx])
+	return m.playSongCmd(&m.songs[m.playlist[nextIdx]])
 }
 
 func (m *model) playPreviousCmd() tea.Cmd {
-	if len(m.songs) == 0 {
+	if len(m.playlist) == 0 {
 		return nil
 	}
 
@@ -487,12 +891,12 @@ func (m *model) playPreviousCmd() tea.Cmd {
 		currentIdx := m.list.Index()
 		prevIdx = currentIdx - 1
 		if prevIdx < 0 {
-			prevIdx = len(m.songs) - 1
+			prevIdx = len(m.playlist) - 1
 		}
 	}
 
 	m.list.Select(prevIdx)
-	return m.playSongCmd(&m.songs[prevIdx])
+	return m.playSongCmd(&m.songs[m.playlist[prevIdx]])
 }
 
 func (m *model) View() string {
@@ -525,14 +929,36 @@ func (m *model) View() string {
 	infoStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241"))
 
+	leftWidth := (m.width * 2 / 3) - 4
+	rightWidth := (m.width / 3) - 2
+
 	var leftPanel string
+	var art artwork.Rendered
+	artLine, hasArt := 0, false
 	if m.currentSong != nil {
 		meta := m.currentSong.metadata
 
 		leftPanel = fmt.Sprintf("%s\n\n", titleStyle.Render("♪ Now Playing"))
-		leftPanel += fmt.Sprintf("Title:  %s\n", meta.Title)
-		leftPanel += fmt.Sprintf("Artist: %s\n", meta.Artist)
-		leftPanel += fmt.Sprintf("Album:  %s\n\n", meta.Album)
+
+		trackInfo := fmt.Sprintf("Title:  %s\n", meta.Title)
+		trackInfo += fmt.Sprintf("Artist: %s\n", meta.Artist)
+		trackInfo += fmt.Sprintf("Album:  %s\n", meta.Album)
+		trackInfo += formatExtraTags(meta)
+		if idx := meta.ChapterAt(m.currentTime); idx >= 0 {
+			trackInfo += fmt.Sprintf("Chapter: %d/%d %s\n", idx+1, len(meta.Chapters), meta.Chapters[idx].Title)
+		}
+
+		// Left panel content width: leftStyle pads 2 cells on each side.
+		panelWidth := leftWidth - 4
+		if art, hasArt = m.coverArt(panelWidth); hasArt {
+			trackInfoStyle := lipgloss.NewStyle().Width(panelWidth - art.Cols - 2)
+			artLine = strings.Count(leftPanel, "\n")
+			leftPanel += lipgloss.JoinHorizontal(lipgloss.Top,
+				art.Block, "  ", trackInfoStyle.Render(strings.TrimSuffix(trackInfo, "\n")))
+			leftPanel += "\n\n"
+		} else {
+			leftPanel += trackInfo + "\n"
+		}
 
 		stateStr := "■ Stopped"
 		switch m.state {
@@ -558,6 +984,9 @@ func (m *model) View() string {
 		}
 
 		leftPanel += m.progress.ViewAs(progressPercent) + "\n"
+		if len(meta.Chapters) > 1 {
+			leftPanel += infoStyle.Render(chapterTicks(meta, m.progress.Width)) + "\n"
+		}
 
 		currentMin := int(m.currentTime) / 60
 		currentSec := int(m.currentTime) % 60
@@ -576,33 +1005,48 @@ func (m *model) View() string {
 		"  p: play selected  space: pause/resume\n" +
 		"  s: stop           n/→: next  b/←: prev\n" +
 		"  t: forward 5s     r: rewind 5s\n" +
-		"  h: shuffle        q: quit\n"))
+		"  h: shuffle        q: quit\n" +
+		"  e: edit tags      m: mark  M: clear marks\n" +
+		"  o: override       x: hide  H: show hidden\n" +
+		"  i: scan issues    a: song info/audio stream\n" +
+		"  [/]: chapters     C: chapter list\n" +
+		"  P: play from start  R: continue listening\n" +
+		"  D: find duplicates\n" +
+		"  J/K: scroll lyrics  L: follow lyrics\n" +
+		"  F: find lyrics    </>: lyrics sooner/later\n" +
+		"  S: sync plain lyrics\n"))
+
+	if m.status != "" && time.Since(m.statusTime) < statusTimeout {
+		leftPanel += "\n" + m.status + "\n"
+	}
 
-	lyricsSection := "\n" + titleStyle.Render("Lyrics") + "\n\n"
+	lyricsSection := "\n" + titleStyle.Render("Lyrics")
+	if song := m.currentSong; song != nil && song.lyrics != nil && len(song.lyrics.Lines) > 0 && song.lyrics.Offset != 0 {
+		lyricsSection += infoStyle.Render("  offset " + formatLyricsOffset(song.lyrics.Offset))
+	}
+	lyricsSection += "\n\n"
 
 	if m.currentSong != nil {
 		if m.lyricsLoading {
 			lyricsSection += infoStyle.Render("Loading lyrics...\n\n")
 		} else if m.currentSong.lyrics != nil && m.currentSong.lyrics.Loaded {
-			if len(m.currentSong.lyrics.Lines) == 0 {
+			ly := m.currentSong.lyrics
+			switch {
+			case ly.Instrumental:
+				lyricsSection += infoStyle.Render("♪ Instrumental\n\n")
+			case len(ly.Lines) == 0 && ly.Plain != "":
+				lyricsSection += lyricUnsyncedStyle.Render("UNSYNCED") + infoStyle.Render("  J/K: scroll  S: sync them") + "\n"
+				height := m.lyricsHeight(lipgloss.Height(leftPanel + lyricsSection))
+				lyricsSection += m.lyricsPanel.renderPlain(m.currentSong, leftWidth-4, height) + "\n"
+			case len(ly.Lines) == 0:
 				lyricsSection += infoStyle.Render("No lyrics available for this song.\n\n")
-			} else {
-				current, next := getCurrentLyrics(m.currentSong.lyrics, m.currentTime)
-
-				currentStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("230")).
-					Bold(true)
-
-				nextStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("241"))
-
-				if current != "" {
-					lyricsSection += currentStyle.Render(current) + "\n"
-				}
-				if next != "" {
-					lyricsSection += nextStyle.Render(next) + "\n"
+			default:
+				// Left panel content width: leftStyle pads 2 cells on each side.
+				height := m.lyricsHeight(lipgloss.Height(leftPanel + lyricsSection))
+				lyricsSection += m.lyricsPanel.render(m.currentSong, m.currentTime, leftWidth-4, height) + "\n"
+				if m.lyricsPanel.manual {
+					lyricsSection += infoStyle.Render("Scrolled by hand; press L to follow again") + "\n"
 				}
-				lyricsSection += "\n"
 			}
 		} else {
 			lyricsSection += infoStyle.Render("Lyrics not loaded\n\n")
@@ -613,11 +1057,14 @@ func (m *model) View() string {
 
 	leftPanel += lyricsSection
 
+	if m.overlay != nil {
+		leftPanel = m.overlay.view(m, leftWidth-4)
+		hasArt = false
+	}
+
 	rightPanel := m.list.View()
 
 	if m.currentSong != nil {
-		meta := m.currentSong.metadata
-
 		audioInfoStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
 			BorderTop(true).
@@ -626,18 +1073,11 @@ func (m *model) View() string {
 			PaddingTop(1).
 			MarginTop(1)
 
-		audioInfo := audioInfoStyle.Render(
-			fmt.Sprintf("Bitrate:     %s\n", meta.Bitrate) +
-				fmt.Sprintf("Codec:       %s\n", meta.Codec) +
-				fmt.Sprintf("Sample Rate: %s", meta.SampleRate),
-		)
+		audioInfo := audioInfoStyle.Render(formatAudioInfo(m.currentSong.playingMetadata()))
 
 		rightPanel += "\n" + audioInfo
 	}
 
-	leftWidth := (m.width * 2 / 3) - 4
-	rightWidth := (m.width / 3) - 2
-
 	leftStyle := lipgloss.NewStyle().
 		Width(leftWidth).
 		Padding(1, 2)
@@ -648,55 +1088,26 @@ func (m *model) View() string {
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color("62"))
 
-	return lipgloss.JoinHorizontal(
+	view := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		leftStyle.Render(leftPanel),
 		rightStyle.Render(rightPanel),
 	)
-}
 
-func loadLyricsAsync(song *Song, musicDir string) tea.Cmd {
-	return func() tea.Msg {
-		if lrc, found := lyrics.LoadFromFile(song.metadata.FilePath, musicDir); found {
-			return lyricsLoadedMsg{song: song, lyrics: &lrc}
-		}
-
-		content, err := lyrics.FetchFromAPI(song.metadata.Artist, song.metadata.Title, song.metadata.Album)
-		if err != nil {
-			return lyricsLoadedMsg{song: song, lyrics: &lyrics.Lyrics{Loaded: true}}
-		}
-
-		if _, err := lyrics.SaveToFile(song.metadata.FilePath, musicDir, content); err != nil {
-			return lyricsLoadedMsg{song: song, lyrics: &lyrics.Lyrics{Loaded: true}}
-		}
-
-		lines := lyrics.Parse(content)
-		return lyricsLoadedMsg{song: song, lyrics: &lyrics.Lyrics{Lines: lines, Loaded: true}}
+	if hasArt {
+		// leftStyle adds one line of top padding and two cells on the left.
+		view = applyArtOverlays(view, art, artLine+1, 3)
 	}
-}
 
-func getCurrentLyrics(ly *lyrics.Lyrics, currentTime float64) (current, next string) {
-	if ly == nil || !ly.Loaded || len(ly.Lines) == 0 {
-		return "", ""
-	}
-
-	currentIdx := -1
-	for i, line := range ly.Lines {
-		if line.Time <= currentTime {
-			currentIdx = i
-		} else {
-			break
-		}
-	}
+	return view
+}
 
-	if currentIdx >= 0 {
-		current = ly.Lines[currentIdx].Text
-		if currentIdx+1 < len(ly.Lines) {
-			next = ly.Lines[currentIdx+1].Text
-		}
-	} else if len(ly.Lines) > 0 {
-		next = ly.Lines[0].Text
+// lyricsName is the name a song's LRC file is stored under: the file name,
+// or "Artist - Title" for tracks of a CUE sheet, which share their file.
+func lyricsName(meta media.Metadata) string {
+	if !meta.IsCueTrack() {
+		return strings.TrimSuffix(filepath.Base(meta.FilePath), filepath.Ext(meta.FilePath))
 	}
-
-	return current, next
+	name := meta.Artist + " - " + meta.Title
+	return strings.NewReplacer("/", "_", `\`, "_", ":", "_").Replace(name)
 }
@@ -0,0 +1,33 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// overlay is a modal panel drawn in place of the Now Playing panel while
+// the playlist stays visible. It receives every message the model does not
+// handle itself, and all key presses except ctrl+c. Returning a nil overlay
+// from update closes it.
+type overlay interface {
+	update(m *model, msg tea.Msg) (overlay, tea.Cmd)
+	view(m *model, width int) string
+}
+
+var (
+	overlayTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("230")).
+				Background(lipgloss.Color("63")).
+				Padding(0, 1)
+
+	overlayHelpStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("241"))
+
+	overlayErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("203"))
+
+	overlaySelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("212")).
+				Bold(true)
+)
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"strings"
+
+	"Player/internal/config"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LoadOverrides opens the metadata overrides of the library made of roots,
+// which the first root names. It returns nil without an error when the
+// system has no user config directory, in which case overrides are simply
+// unavailable.
+func LoadOverrides(roots []string) (*media.Overrides, error) {
+	path, err := config.OverridesPath(roots[0])
+	if err != nil {
+		return nil, nil
+	}
+	return media.LoadOverrides(path, roots)
+}
+
+// overrideEditor corrects the selected song through the library overrides
+// instead of rewriting the file, for libraries on read-only storage.
+type overrideEditor struct {
+	song     media.Metadata
+	override media.Override
+	form     form
+	saving   bool
+	err      string
+}
+
+type overrideAppliedMsg struct {
+	id   string
+	meta media.Metadata
+	err  error
+}
+
+func (m *model) openOverrideEditor() tea.Cmd {
+	if m.scanOpts.Overrides == nil {
+		m.setStatus("Overrides are unavailable: no config directory")
+		return nil
+	}
+	idx := m.selectedSongIndex()
+	if idx < 0 {
+		return nil
+	}
+
+	meta := m.songs[idx].metadata
+	ov, _ := m.scanOpts.Overrides.Lookup(meta)
+
+	editor := &overrideEditor{song: meta, override: ov}
+	editor.form.addField("Title", ov.Title, meta.Title)
+	editor.form.addField("Artist", ov.Artist, meta.Artist)
+	editor.form.addField("Album", ov.Album, meta.Album)
+	editor.form.addField("Sort Key", ov.SortKey, "(library path)")
+
+	m.overlay = editor
+	return editor.form.start()
+}
+
+func (e *overrideEditor) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	if _, isKey := msg.(tea.KeyMsg); isKey && e.saving {
+		return e, nil
+	}
+
+	action, cmd := e.form.update(msg)
+	switch action {
+	case formCancel:
+		return nil, nil
+	case formSubmit:
+		ov := e.override
+		ov.Title = e.form.value(0)
+		ov.Artist = e.form.value(1)
+		ov.Album = e.form.value(2)
+		ov.SortKey = e.form.value(3)
+		e.saving = true
+		return e, m.setOverrideCmd(e.song, ov)
+	}
+	return e, cmd
+}
+
+func (e *overrideEditor) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Override Metadata") + "\n\n")
+	b.WriteString(overlayHelpStyle.Render(songLocation(e.song)) + "\n")
+	b.WriteString(overlayHelpStyle.Render("Stored with the library settings; the file is not modified.") + "\n\n")
+	b.WriteString(e.form.view(width))
+
+	b.WriteString("\n")
+	switch {
+	case e.saving:
+		b.WriteString("Saving override...\n")
+	case e.err != "":
+		b.WriteString(overlayErrorStyle.Render(e.err) + "\n")
+	}
+
+	b.WriteString(overlayHelpStyle.Render("\nempty fields keep the file's tags\ntab/↑↓: move  enter: save  esc: cancel"))
+	return b.String()
+}
+
+// setOverrideCmd stores an override and re-probes the song so it picks up
+// the result, including fields an emptied override no longer replaces.
+func (m *model) setOverrideCmd(song media.Metadata, ov media.Override) tea.Cmd {
+	opts := m.scanOpts
+	return func() tea.Msg {
+		if err := opts.Overrides.Set(song, ov); err != nil {
+			return overrideAppliedMsg{id: song.ID(), err: err}
+		}
+		meta, err := media.Reprobe(song, opts)
+		return overrideAppliedMsg{id: song.ID(), meta: meta, err: err}
+	}
+}
+
+func (m *model) applyOverrideResult(msg overrideAppliedMsg) tea.Cmd {
+	editor, _ := m.overlay.(*overrideEditor)
+	if msg.err != nil {
+		if editor != nil {
+			editor.saving = false
+			editor.err = msg.err.Error()
+		} else {
+			m.setStatus("Failed to save override: %v", msg.err)
+		}
+		return nil
+	}
+
+	if editor != nil {
+		m.overlay = nil
+	}
+	for i := range m.songs {
+		if m.songs[i].metadata.ID() == msg.id {
+			m.songs[i].metadata = msg.meta
+			break
+		}
+	}
+	m.setStatus("Override saved")
+	return m.rebuildPlaylist()
+}
+
+// toggleHidden hides the selected song from the playlist, or brings it back
+// when hidden songs are shown.
+func (m *model) toggleHidden() tea.Cmd {
+	if m.scanOpts.Overrides == nil {
+		m.setStatus("Overrides are unavailable: no config directory")
+		return nil
+	}
+	idx := m.selectedSongIndex()
+	if idx < 0 {
+		return nil
+	}
+
+	meta := &m.songs[idx].metadata
+	if err := m.setHidden(idx, !meta.Hidden); err != nil {
+		m.setStatus("Failed to save override: %v", err)
+		return nil
+	}
+
+	if meta.Hidden {
+		m.setStatus("Hidden %q (H shows hidden songs)", meta.Title)
+	} else {
+		m.setStatus("Unhidden %q", meta.Title)
+	}
+	return m.rebuildPlaylist()
+}
+
+// setHidden saves whether m.songs[idx] is hidden in the overrides.
+func (m *model) setHidden(idx int, hidden bool) error {
+	meta := &m.songs[idx].metadata
+	ov, _ := m.scanOpts.Overrides.Lookup(*meta)
+	ov.Hidden = hidden
+	if err := m.scanOpts.Overrides.Set(*meta, ov); err != nil {
+		return err
+	}
+	meta.Hidden = hidden
+	return nil
+}
+
+// hiddenCount returns how many songs overrides hide.
+func (m *model) hiddenCount() int {
+	count := 0
+	for _, song := range m.songs {
+		if song.metadata.Hidden && !song.removed {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *model) toggleShowHidden() tea.Cmd {
+	m.showHidden = !m.showHidden
+	if m.showHidden {
+		m.setStatus("Showing %d hidden song(s)", m.hiddenCount())
+	} else {
+		m.setStatus("Hiding %d song(s)", m.hiddenCount())
+	}
+	return m.rebuildPlaylist()
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rebuildPlaylist recomputes which songs the list shows and in what order.
+// m.songs is never reordered or shrunk, so pointers into it stay valid;
+// deleted songs are marked removed and left out. m.playlist maps list
+// positions to indices in m.songs.
+func (m *model) rebuildPlaylist() tea.Cmd {
+	selected := ""
+	if idx := m.selectedSongIndex(); idx >= 0 {
+		selected = m.songs[idx].metadata.ID()
+	}
+
+	m.playlist = m.playlist[:0]
+	for i, song := range m.songs {
+		if !song.removed && (!song.metadata.Hidden || m.showHidden) {
+			m.playlist = append(m.playlist, i)
+		}
+	}
+
+	keys := make(map[int][]string, len(m.playlist))
+	for _, idx := range m.playlist {
+		keys[idx] = m.sortKey(&m.songs[idx])
+	}
+	sort.SliceStable(m.playlist, func(a, b int) bool {
+		return comparePathKeys(keys[m.playlist[a]], keys[m.playlist[b]]) < 0
+	})
+
+	items := make([]list.Item, len(m.playlist))
+	reselect := -1
+	for pos, idx := range m.playlist {
+		items[pos] = m.songs[idx]
+		if m.songs[idx].metadata.ID() == selected {
+			reselect = pos
+		}
+	}
+	m.playHistory = make([]int, 0)
+
+	cmd := m.list.SetItems(items)
+	if reselect >= 0 {
+		m.list.Select(reselect)
+	}
+	return cmd
+}
+
+// sortKey orders a song by its override sort key, or by its path inside the
+// library. Both are split on "/" and compared component by component, which
+// keeps the folder order of the scan for songs without a key and merges the
+// folders of several roots.
+func (m *model) sortKey(song *Song) []string {
+	key := song.metadata.SortKey
+	if key == "" {
+		key = filepath.ToSlash(m.libraryPath(song.metadata.FilePath))
+	}
+	return strings.Split(key, "/")
+}
+
+// libraryPath returns path relative to the library root containing it, or
+// path itself when it is under none of them.
+func (m *model) libraryPath(path string) string {
+	for _, root := range m.roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
+		}
+	}
+	return path
+}
+
+func comparePathKeys(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// selectedSongIndex returns the index in m.songs of the highlighted list
+// item, or -1 when nothing is selected.
+func (m *model) selectedSongIndex() int {
+	selectedItem := m.list.SelectedItem()
+	if selectedItem == nil {
+		return -1
+	}
+	selectedSong := selectedItem.(Song)
+	for i := range m.songs {
+		if m.songs[i].metadata.ID() == selectedSong.metadata.ID() {
+			return i
+		}
+	}
+	return -1
+}
+
+// refreshSong pushes m.songs[idx] back into the list after it changed. The
+// list holds copies, so edits are invisible until refreshed.
+func (m *model) refreshSong(idx int) tea.Cmd {
+	for pos, songIdx := range m.playlist {
+		if songIdx == idx {
+			return m.list.SetItem(pos, m.songs[idx])
+		}
+	}
+	return nil
+}
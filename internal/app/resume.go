@@ -0,0 +1,265 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"Player/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// resumeMinPosition is how far into a track playback must get before
+	// its position is worth remembering.
+	resumeMinPosition = 15.0
+
+	// resumeEndMargin treats a track stopped this close to its end as
+	// finished, so credits or applause don't leave it "in progress".
+	resumeEndMargin = 30.0
+
+	// resumeSaveInterval is how often the position of a playing track is
+	// saved, so a crash loses little.
+	resumeSaveInterval = 30 * time.Second
+)
+
+// resumeEntry is the saved position of one song.
+type resumeEntry struct {
+	Position float64   `json:"position"`
+	Duration float64   `json:"duration"`
+	Title    string    `json:"title"`
+	Artist   string    `json:"artist"`
+	Updated  time.Time `json:"updated"`
+}
+
+// resumeStore remembers where long tracks were left off. Entries are keyed
+// by song ID and saved to the positions file in the user config directory.
+type resumeStore struct {
+	path        string
+	minDuration float64
+	entries     map[string]resumeEntry
+	lastSave    time.Time
+}
+
+// loadResumeStore opens the saved positions. It returns nil without an error
+// when resuming is turned off or there is no user config directory.
+func loadResumeStore(cfg config.Config) (*resumeStore, error) {
+	minDuration := cfg.ResumeMinDuration
+	if minDuration == 0 {
+		minDuration = config.DefaultResumeMinDuration
+	}
+	if minDuration < 0 {
+		return nil, nil
+	}
+	path, err := config.PositionsPath()
+	if err != nil {
+		return nil, nil
+	}
+
+	store := &resumeStore{
+		path:        path,
+		minDuration: minDuration,
+		entries:     make(map[string]resumeEntry),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// position returns where to start song, or 0 to start from the beginning.
+func (s *resumeStore) position(song *Song) float64 {
+	if s == nil {
+		return 0
+	}
+	entry, ok := s.entries[song.metadata.ID()]
+	if !ok || entry.Position >= song.metadata.Duration {
+		return 0
+	}
+	return entry.Position
+}
+
+// record remembers pos for song, or forgets the song when it is too short,
+// barely started or finished. The file is written when anything changed.
+func (s *resumeStore) record(song *Song, pos float64) error {
+	if s == nil || song == nil {
+		return nil
+	}
+	meta := song.metadata
+	id := meta.ID()
+
+	if meta.Duration < s.minDuration || pos < resumeMinPosition || pos > meta.Duration-resumeEndMargin {
+		if _, ok := s.entries[id]; !ok {
+			return nil
+		}
+		delete(s.entries, id)
+		return s.save()
+	}
+
+	s.entries[id] = resumeEntry{
+		Position: pos,
+		Duration: meta.Duration,
+		Title:    meta.Title,
+		Artist:   meta.Artist,
+		Updated:  time.Now(),
+	}
+	return s.save()
+}
+
+// forget drops the saved position of a song.
+func (s *resumeStore) forget(id string) error {
+	if s == nil {
+		return nil
+	}
+	delete(s.entries, id)
+	return s.save()
+}
+
+// save writes the positions atomically through a temporary file.
+func (s *resumeStore) save() error {
+	s.lastSave = time.Now()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".positions-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// saveCurrentPosition records where the current song is. Failures only
+// show up in the status line; losing a position is not worth more.
+func (m *model) saveCurrentPosition() {
+	if m.currentSong == nil {
+		return
+	}
+	if err := m.resume.record(m.currentSong, m.currentTime); err != nil {
+		m.setStatus("Failed to save position: %v", err)
+	}
+}
+
+// inProgressSong is a song of the library with a saved position.
+type inProgressSong struct {
+	songIdx int
+	entry   resumeEntry
+}
+
+// inProgress returns the library's songs with a saved position, most
+// recently played first.
+func (m *model) inProgress() []inProgressSong {
+	if m.resume == nil {
+		return nil
+	}
+	var list []inProgressSong
+	for i := range m.songs {
+		if entry, ok := m.resume.entries[m.songs[i].metadata.ID()]; ok {
+			list = append(list, inProgressSong{songIdx: i, entry: entry})
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].entry.Updated.After(list[b].entry.Updated)
+	})
+	return list
+}
+
+// inProgressView lists the songs that were left part-way through.
+type inProgressView struct {
+	cursor int
+}
+
+func (m *model) openInProgress() {
+	if m.resume == nil {
+		m.setStatus("Resume positions are off")
+		return
+	}
+	m.overlay = &inProgressView{}
+}
+
+func (v *inProgressView) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return v, nil
+	}
+
+	list := m.inProgress()
+	switch keyMsg.String() {
+	case "esc", "q", "R":
+		return nil, nil
+	case "down", "j":
+		if v.cursor < len(list)-1 {
+			v.cursor++
+		}
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "enter":
+		if v.cursor < len(list) {
+			return nil, m.playSongCmd(&m.songs[list[v.cursor].songIdx])
+		}
+	case "d", "delete":
+		if v.cursor < len(list) {
+			if err := m.resume.forget(m.songs[list[v.cursor].songIdx].metadata.ID()); err != nil {
+				m.setStatus("Failed to save positions: %v", err)
+			}
+			if v.cursor >= len(list)-1 && v.cursor > 0 {
+				v.cursor--
+			}
+		}
+	}
+	return v, nil
+}
+
+func (v *inProgressView) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Continue Listening") + "\n\n")
+
+	list := m.inProgress()
+	if len(list) == 0 {
+		b.WriteString("Nothing in progress.\n")
+		b.WriteString(overlayHelpStyle.Render(fmt.Sprintf("\nTracks of %s or longer are remembered when stopped part-way.\nesc: close", formatClock(m.resume.minDuration))))
+		return b.String()
+	}
+
+	for i, item := range list {
+		meta := m.songs[item.songIdx].metadata
+		progress := fmt.Sprintf("%s / %s", formatClock(item.entry.Position), formatClock(item.entry.Duration))
+		line := ansi.Truncate(fmt.Sprintf("%s  %s - %s", progress, meta.Artist, meta.Title), width, "…")
+		if i == v.cursor {
+			line = overlaySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString(overlayHelpStyle.Render("\n↑↓: choose  enter: resume  d: forget  esc: close"))
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// scanIssuesView lists the files and folders the library scan skipped, with
+// the reason for each.
+type scanIssuesView struct {
+	offset int
+}
+
+func (v *scanIssuesView) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return v, nil
+	}
+
+	page := v.pageSize(m)
+	switch keyMsg.String() {
+	case "esc", "q", "i":
+		return nil, nil
+	case "down", "j":
+		v.scroll(m, 1)
+	case "up", "k":
+		v.scroll(m, -1)
+	case "pgdown", " ":
+		v.scroll(m, page)
+	case "pgup":
+		v.scroll(m, -page)
+	case "home", "g":
+		v.offset = 0
+	case "end", "G":
+		v.scroll(m, len(m.scanIssues))
+	}
+	return v, nil
+}
+
+// pageSize is how many issues fit on screen; each takes two lines.
+func (v *scanIssuesView) pageSize(m *model) int {
+	page := (m.height - 12) / 2
+	if page < 1 {
+		page = 1
+	}
+	return page
+}
+
+func (v *scanIssuesView) scroll(m *model, delta int) {
+	maxOffset := len(m.scanIssues) - v.pageSize(m)
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	v.offset += delta
+	if v.offset > maxOffset {
+		v.offset = maxOffset
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+}
+
+func (v *scanIssuesView) view(m *model, width int) string {
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Scan Issues") + "\n\n")
+
+	if len(m.scanIssues) == 0 {
+		b.WriteString("Every file in the library loaded.\n")
+		b.WriteString(overlayHelpStyle.Render("\nesc: close"))
+		return b.String()
+	}
+
+	b.WriteString(overlayHelpStyle.Render(fmt.Sprintf("%d file(s) skipped", len(m.scanIssues))) + "\n\n")
+
+	end := v.offset + v.pageSize(m)
+	if end > len(m.scanIssues) {
+		end = len(m.scanIssues)
+	}
+	for _, issue := range m.scanIssues[v.offset:end] {
+		path := m.libraryPath(issue.Path)
+		b.WriteString(ansi.Truncate(path, width, "…") + "\n")
+		b.WriteString(overlayErrorStyle.Render(ansi.Truncate("  "+issue.Err.Error(), width, "…")) + "\n")
+	}
+
+	if len(m.scanIssues) > end-v.offset {
+		b.WriteString(overlayHelpStyle.Render(fmt.Sprintf("\n%d-%d of %d", v.offset+1, end, len(m.scanIssues))))
+	}
+	b.WriteString(overlayHelpStyle.Render("\n↑↓/pgup/pgdn: scroll  esc: close"))
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Player/internal/media"
+)
+
+func TestSeekPastEndPlaysNext(t *testing.T) {
+	dir := t.TempDir()
+	m := initialModel([]string{dir}, media.Options{})
+	m.loading = false
+	for _, name := range []string{"01.flac", "02.flac"} {
+		m.songs = append(m.songs, Song{metadata: media.Metadata{
+			FilePath: filepath.Join(dir, name),
+			Title:    name,
+			Duration: 200,
+		}, audioStream: -1})
+	}
+	m.rebuildPlaylist()
+	m.currentSong = &m.songs[0]
+	m.currentTime = 198
+
+	done := make(chan bool)
+	go func() {
+		done <- m.seek(5) != nil
+	}()
+	select {
+	case gotCmd := <-done:
+		if !gotCmd {
+			t.Error("seeking past the end returned no Cmd")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("seeking past the end deadlocked")
+	}
+	if m.currentSong != &m.songs[1] {
+		t.Errorf("current song = %s, want the next one", m.currentSong.metadata.Title)
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"Player/internal/lyrics"
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// songInfoView shows the technical details of the selected song and lets
+// the user pick which of its audio streams is played.
+type songInfoView struct {
+	songIdx int
+	cursor  int
+}
+
+// openSongInfo opens the view for the highlighted song, with the cursor on
+// the stream it currently plays.
+func (m *model) openSongInfo() {
+	idx := m.selectedSongIndex()
+	if idx < 0 {
+		return
+	}
+	song := &m.songs[idx]
+	view := &songInfoView{songIdx: idx}
+	current := song.audioStream
+	if current < 0 {
+		current = song.metadata.DefaultAudioStream().Index
+	}
+	for i, st := range song.metadata.AudioStreams {
+		if st.Index == current {
+			view.cursor = i
+		}
+	}
+	m.overlay = view
+}
+
+func (v *songInfoView) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return v, nil
+	}
+
+	streams := m.songs[v.songIdx].metadata.AudioStreams
+	switch keyMsg.String() {
+	case "esc", "q", "a":
+		return nil, nil
+	case "down", "j", "tab":
+		if len(streams) > 0 {
+			v.cursor = (v.cursor + 1) % len(streams)
+		}
+	case "up", "k", "shift+tab":
+		if len(streams) > 0 {
+			v.cursor = (v.cursor - 1 + len(streams)) % len(streams)
+		}
+	case "enter":
+		if v.cursor < len(streams) {
+			m.selectAudioStream(v.songIdx, streams[v.cursor])
+		}
+		return nil, nil
+	}
+	return v, nil
+}
+
+// selectAudioStream makes the song play the given stream. A playing song
+// switches over at its current position.
+func (m *model) selectAudioStream(songIdx int, st media.AudioStream) {
+	song := &m.songs[songIdx]
+	if song.audioStream == st.Index {
+		return
+	}
+	song.audioStream = st.Index
+	m.setStatus("Audio stream: %s", formatAudioStream(st))
+
+	if m.currentSong == song && m.state == statePlaying {
+		m.mu.Lock()
+		m.lastUpdateTime = time.Now()
+		if err := m.engine.Play(song.source(), m.currentTime, 100); err != nil {
+			m.state = stateStopped
+			m.setStatus("Failed to play %s: %v", song.metadata.Title, err)
+		}
+		m.mu.Unlock()
+	} else if m.currentSong == song && m.state == statePaused {
+		m.engine.SetSource(song.source())
+	}
+}
+
+func (v *songInfoView) view(m *model, width int) string {
+	song := &m.songs[v.songIdx]
+	meta := song.playingMetadata()
+
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Song Info") + "\n\n")
+	b.WriteString(meta.Title + "\n")
+	b.WriteString(overlayHelpStyle.Render(ansi.Truncate(songLocation(meta), width, "…")) + "\n\n")
+
+	if meta.Duration > 0 {
+		fmt.Fprintf(&b, "Duration:    %d:%02d\n", int(meta.Duration)/60, int(meta.Duration)%60)
+	}
+	b.WriteString(formatAudioInfo(meta) + "\n")
+	if meta.HasVideo {
+		b.WriteString("Container:   video (audio only is played)\n")
+	}
+	if lyricsInfo := formatLyricsMatch(song.lyrics); lyricsInfo != "" {
+		b.WriteString("Lyrics:      " + lyricsInfo + "\n")
+	}
+
+	b.WriteString("\nAudio streams:\n")
+	playing := song.audioStream
+	if playing < 0 {
+		playing = meta.DefaultAudioStream().Index
+	}
+	for i, st := range meta.AudioStreams {
+		marker := "  "
+		if st.Index == playing {
+			marker = "▶ "
+		}
+		line := ansi.Truncate(marker+formatAudioStream(st), width, "…")
+		if i == v.cursor {
+			line = overlaySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString(overlayHelpStyle.Render("\n↑↓: choose stream  enter: play it  esc: close"))
+	return b.String()
+}
+
+// formatLyricsMatch says where the loaded lyrics came from, to help track
+// down wrong ones.
+func formatLyricsMatch(ly *lyrics.Lyrics) string {
+	if ly == nil || !ly.Loaded {
+		return ""
+	}
+	if !ly.Found() {
+		return "none found"
+	}
+	match := ly.Match
+	if !match.Known() {
+		return "unknown source"
+	}
+	source := match.Provider
+	if match.ID != "" {
+		source += " " + match.ID
+	}
+	return fmt.Sprintf("%s, %.0f%% match", source, match.Confidence*100)
+}
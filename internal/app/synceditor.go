@@ -0,0 +1,257 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"Player/internal/lyrics"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// syncNudgeStep is how far ←/→ move the last stamp, in seconds.
+	syncNudgeStep = 0.1
+
+	// syncUndoRewind is how far before an undone stamp playback restarts,
+	// so the line can be tapped again.
+	syncUndoRewind = 2.0
+)
+
+// syncEditor turns plain lyrics into synced ones: the song plays from the
+// start and the user taps a key as each line begins.
+type syncEditor struct {
+	song   *Song
+	lines  []string
+	stamps []float64 // stamps[i] is when lines[i] starts; the rest are to do
+
+	// discard is set after esc with unsaved stamps; a second esc closes.
+	discard bool
+	err     string
+}
+
+// openSyncEditor starts syncing the playing song's plain lyrics, playing it
+// again from the start.
+func (m *model) openSyncEditor() tea.Cmd {
+	song := m.currentSong
+	if song == nil || song.lyrics == nil || len(song.lyrics.Lines) > 0 || song.lyrics.Plain == "" {
+		m.setStatus("The sync editor needs a playing song with unsynced lyrics")
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(song.lyrics.Plain, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	m.overlay = &syncEditor{song: song, lines: lines}
+	return m.playSongFromCmd(song, 0)
+}
+
+func (e *syncEditor) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return e, nil
+	}
+
+	if keyMsg.String() != "esc" {
+		e.discard = false
+	}
+	e.err = ""
+	switch keyMsg.String() {
+	case "esc":
+		if len(e.stamps) > 0 && !e.discard {
+			e.discard = true
+			return e, nil
+		}
+		return nil, nil
+
+	case "enter":
+		e.stamp(m)
+
+	case "backspace", "u":
+		if len(e.stamps) == 0 {
+			return e, nil
+		}
+		last := e.stamps[len(e.stamps)-1]
+		e.stamps = e.stamps[:len(e.stamps)-1]
+		if m.currentSong == e.song && m.state != stateStopped {
+			return e, m.seekTo(math.Max(last-syncUndoRewind, 0))
+		}
+
+	case "left", "right":
+		if len(e.stamps) == 0 {
+			return e, nil
+		}
+		step := syncNudgeStep
+		if keyMsg.String() == "left" {
+			step = -step
+		}
+		i := len(e.stamps) - 1
+		low := 0.0
+		if i > 0 {
+			low = e.stamps[i-1]
+		}
+		e.stamps[i] = math.Max(e.stamps[i]+step, low)
+
+	case " ":
+		switch {
+		case m.currentSong != e.song || m.state == stateStopped:
+			return e, m.playSongFromCmd(e.song, 0)
+		case m.state == statePlaying:
+			m.pausePlayback()
+		default:
+			m.resumePlayback()
+		}
+
+	case "r":
+		return e, m.seek(-5)
+
+	case "t":
+		return e, m.seek(5)
+
+	case "ctrl+s":
+		if left := len(e.lines) - len(e.stamps); left > 0 {
+			e.err = fmt.Sprintf("Stamp every line before saving (%d left)", left)
+			return e, nil
+		}
+		if err := e.save(m); err != nil {
+			e.err = err.Error()
+			return e, nil
+		}
+		m.setStatus("Synced lyrics saved")
+		return nil, nil
+	}
+	return e, nil
+}
+
+// stamp marks the next line as starting now.
+func (e *syncEditor) stamp(m *model) {
+	if len(e.stamps) == len(e.lines) {
+		return
+	}
+	if m.currentSong != e.song || m.state == stateStopped {
+		e.err = "Playback stopped; press space to start over"
+		return
+	}
+	now := m.currentTime
+	if n := len(e.stamps); n > 0 && now < e.stamps[n-1] {
+		e.err = "That is before the previous line; undo it first"
+		return
+	}
+	e.stamps = append(e.stamps, now)
+}
+
+// lrc renders the stamped lines as an LRC file.
+func (e *syncEditor) lrc() string {
+	meta := e.song.metadata
+	var b strings.Builder
+	if meta.Title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", meta.Title)
+	}
+	if meta.Artist != "" {
+		fmt.Fprintf(&b, "[ar:%s]\n", meta.Artist)
+	}
+	if meta.Album != "" && meta.Album != "Unknown Album" {
+		fmt.Fprintf(&b, "[al:%s]\n", meta.Album)
+	}
+	if meta.Duration > 0 {
+		fmt.Fprintf(&b, "[length:%s]\n", lyrics.FormatTimestamp(meta.Duration))
+	}
+	for i, t := range e.stamps {
+		fmt.Fprintf(&b, "[%s]%s\n", lyrics.FormatTimestamp(t), e.lines[i])
+	}
+	return b.String()
+}
+
+// save writes the LRC file to the lyrics folder, where it takes precedence
+// over the plain text, and shows it right away.
+func (e *syncEditor) save(m *model) error {
+	path, err := lyrics.SaveByName(lyricsName(e.song.metadata), m.musicDir, e.lrc())
+	if err != nil {
+		return err
+	}
+	ly, found := lyrics.LoadPath(path)
+	if !found {
+		return fmt.Errorf("saved lyrics could not be read back from %s", path)
+	}
+	e.song.lyrics = &ly
+	m.lyricsPanel.resync()
+	return nil
+}
+
+func (e *syncEditor) pageSize(m *model) int {
+	page := m.height - 16
+	if page < 3 {
+		page = 3
+	}
+	return page
+}
+
+func (e *syncEditor) view(m *model, width int) string {
+	meta := e.song.metadata
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render("Sync Lyrics") + "\n\n")
+	b.WriteString(ansi.Truncate(meta.Artist+" - "+meta.Title, width, "…") + "\n")
+
+	state := "playing"
+	switch {
+	case m.currentSong != e.song || m.state == stateStopped:
+		state = "stopped"
+	case m.state == statePaused:
+		state = "paused"
+	}
+	fmt.Fprintf(&b, "%s / %s  %s  ·  %d of %d lines stamped\n\n",
+		lyrics.FormatTimestamp(m.currentTime), formatClock(meta.Duration), state, len(e.stamps), len(e.lines))
+
+	// The line the stamps place at the current time, as the panel would
+	// highlight it.
+	active := -1
+	for i, t := range e.stamps {
+		if t <= m.currentTime {
+			active = i
+		}
+	}
+
+	// Keep the next line a third of the way down, with the lines just
+	// stamped above it.
+	next := len(e.stamps)
+	page := e.pageSize(m)
+	first := max(min(next-page/3, len(e.lines)-page), 0)
+	end := min(first+page, len(e.lines))
+
+	for i := first; i < end; i++ {
+		var line string
+		switch {
+		case i < len(e.stamps):
+			text := ansi.Truncate(fmt.Sprintf("  [%s] %s", lyrics.FormatTimestamp(e.stamps[i]), e.lines[i]), width, "…")
+			if i == active {
+				line = lyricCurrentStyle.Render(text)
+			} else {
+				line = lyricPastStyle.Render(text)
+			}
+		case i == next:
+			line = overlaySelectedStyle.Render(ansi.Truncate("▶ "+e.lines[i], width, "…"))
+		default:
+			line = lyricFutureStyle.Render(ansi.Truncate("  "+e.lines[i], width, "…"))
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if e.err != "" {
+		b.WriteString("\n" + overlayErrorStyle.Render(e.err) + "\n")
+	}
+	if e.discard {
+		b.WriteString("\n" + overlayErrorStyle.Render("Discard the stamps? esc: discard  any other key: keep going") + "\n")
+	}
+
+	help := "\nenter: line starts now  u: undo  ←/→: nudge last stamp 0.1s\nspace: pause  r/t: rewind/forward 5s  ctrl+s: save  esc: close"
+	if len(e.stamps) == len(e.lines) {
+		help = "\nAll lines stamped; play on to check them, then ctrl+s to save\nu: undo  ←/→: nudge last stamp  space: pause  r/t: rewind/forward 5s  esc: close"
+	}
+	b.WriteString(overlayHelpStyle.Render(help))
+	return b.String()
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"Player/internal/media"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tagEditor edits the tags of the selected song, or of every marked song at
+// once. In batch mode only album-wide fields are offered, and fields whose
+// values differ between the songs start empty and are left alone unless
+// typed into.
+type tagEditor struct {
+	targets []string
+	form    form
+	tags    []string
+	saving  bool
+	err     string
+}
+
+type tagWriteResult struct {
+	path string
+	meta media.Metadata
+	err  error
+}
+
+type tagsWrittenMsg struct {
+	results []tagWriteResult
+}
+
+var trackValueRegexp = regexp.MustCompile(`^\d+(/\d+)?$`)
+
+// songTagFields lists the editable fields and how to read them from metadata.
+var songTagFields = []struct {
+	label string
+	tag   string
+	batch bool
+	value func(media.Metadata) string
+}{
+	{"Title", media.TagTitle, false, func(meta media.Metadata) string { return meta.Title }},
+	{"Artist", media.TagArtist, true, func(meta media.Metadata) string {
+		return knownValue(meta.Artist, "Unknown Artist")
+	}},
+	{"Album", media.TagAlbum, true, func(meta media.Metadata) string {
+		return knownValue(meta.Album, "Unknown Album")
+	}},
+	{"Album Artist", media.TagAlbumArtist, true, func(meta media.Metadata) string { return meta.AlbumArtist }},
+	{"Track", media.TagTrack, false, func(meta media.Metadata) string {
+		if meta.TrackNumber == 0 {
+			return ""
+		}
+		if meta.TrackTotal > 0 {
+			return fmt.Sprintf("%d/%d", meta.TrackNumber, meta.TrackTotal)
+		}
+		return fmt.Sprintf("%d", meta.TrackNumber)
+	}},
+	{"Year", media.TagDate, true, func(meta media.Metadata) string {
+		if meta.Date != "" {
+			return meta.Date
+		}
+		if meta.Year > 0 {
+			return fmt.Sprintf("%d", meta.Year)
+		}
+		return ""
+	}},
+	{"Genre", media.TagGenre, true, func(meta media.Metadata) string { return meta.Genre }},
+}
+
+// knownValue hides the placeholder media uses for missing tags.
+func knownValue(value, placeholder string) string {
+	if value == placeholder {
+		return ""
+	}
+	return value
+}
+
+// openTagEditor opens the editor for the marked songs, or for the selected
+// song when none are marked.
+func (m *model) openTagEditor() tea.Cmd {
+	var targets []media.Metadata
+	for _, song := range m.songs {
+		if song.marked {
+			targets = append(targets, song.metadata)
+		}
+	}
+	if len(targets) == 0 {
+		idx := m.selectedSongIndex()
+		if idx < 0 {
+			return nil
+		}
+		targets = append(targets, m.songs[idx].metadata)
+	}
+
+	for _, meta := range targets {
+		if meta.IsCueTrack() {
+			m.setStatus("Tracks of a CUE sheet can't be tagged; use o to override them")
+			return nil
+		}
+		if meta.InArchive() {
+			m.setStatus("Files inside an archive can't be tagged; use o to override them")
+			return nil
+		}
+	}
+
+	batch := len(targets) > 1
+	editor := &tagEditor{}
+	for _, meta := range targets {
+		editor.targets = append(editor.targets, meta.FilePath)
+	}
+
+	for _, f := range songTagFields {
+		if batch && !f.batch {
+			continue
+		}
+
+		value := f.value(targets[0])
+		mixed := false
+		for _, meta := range targets[1:] {
+			if f.value(meta) != value {
+				mixed = true
+				break
+			}
+		}
+
+		placeholder := ""
+		if mixed {
+			value = ""
+			placeholder = "(mixed, leave empty to keep)"
+		}
+		editor.form.addField(f.label, value, placeholder)
+		editor.tags = append(editor.tags, f.tag)
+	}
+
+	m.overlay = editor
+	return editor.form.start()
+}
+
+func (e *tagEditor) update(m *model, msg tea.Msg) (overlay, tea.Cmd) {
+	if _, isKey := msg.(tea.KeyMsg); isKey && e.saving {
+		return e, nil
+	}
+
+	action, cmd := e.form.update(msg)
+	switch action {
+	case formCancel:
+		return nil, nil
+	case formSubmit:
+		return e.save(m)
+	}
+	return e, cmd
+}
+
+// save validates the changed fields and writes them in the background. The
+// editor closes straight away when nothing changed.
+func (e *tagEditor) save(m *model) (overlay, tea.Cmd) {
+	changes := make(map[string]string)
+	for i, tag := range e.tags {
+		if !e.form.changed(i) {
+			continue
+		}
+		value := e.form.value(i)
+		if tag == media.TagTrack && value != "" && !trackValueRegexp.MatchString(value) {
+			e.err = "Track must be a number like 3 or 3/12"
+			return e, nil
+		}
+		changes[tag] = value
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	e.saving = true
+	e.err = ""
+	targets := e.targets
+	opts := m.scanOpts
+	return e, func() tea.Msg {
+		results := make([]tagWriteResult, 0, len(targets))
+		for _, path := range targets {
+			result := tagWriteResult{path: path}
+			if result.err = media.WriteTags(path, changes); result.err == nil {
+				result.meta, result.err = media.Probe(path, opts)
+			}
+			results = append(results, result)
+		}
+		return tagsWrittenMsg{results: results}
+	}
+}
+
+func (e *tagEditor) view(m *model, width int) string {
+	title := "Edit Tags"
+	if len(e.targets) > 1 {
+		title = fmt.Sprintf("Edit Tags (%d songs)", len(e.targets))
+	}
+
+	var b strings.Builder
+	b.WriteString(overlayTitleStyle.Render(title) + "\n\n")
+
+	if len(e.targets) == 1 {
+		b.WriteString(overlayHelpStyle.Render(e.targets[0]) + "\n\n")
+	}
+
+	b.WriteString(e.form.view(width))
+
+	b.WriteString("\n")
+	switch {
+	case e.saving:
+		b.WriteString("Writing tags...\n")
+	case e.err != "":
+		b.WriteString(overlayErrorStyle.Render(e.err) + "\n")
+	}
+
+	b.WriteString(overlayHelpStyle.Render("\ntab/↑↓: move  enter: save  esc: cancel"))
+	return b.String()
+}
+
+// applyTagResults updates the songs whose tags were rewritten. Marks are
+// cleared on success; failures keep the editor open with the errors.
+func (m *model) applyTagResults(msg tagsWrittenMsg) tea.Cmd {
+	var cmds []tea.Cmd
+	var failures []string
+
+	for _, result := range msg.results {
+		if result.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", filepath.Base(result.path), result.err))
+			continue
+		}
+		for i := range m.songs {
+			if m.songs[i].metadata.FilePath == result.path {
+				m.songs[i].metadata = result.meta
+				m.songs[i].marked = false
+				cmds = append(cmds, m.refreshSong(i))
+				break
+			}
+		}
+	}
+
+	editor, _ := m.overlay.(*tagEditor)
+	if len(failures) == 0 {
+		if editor != nil {
+			m.overlay = nil
+		}
+		m.setStatus("Updated tags of %d song(s)", len(msg.results))
+	} else if editor != nil {
+		editor.saving = false
+		editor.err = strings.Join(failures, "\n")
+	} else {
+		m.setStatus("Failed to write tags: %s", failures[0])
+	}
+
+	return tea.Batch(cmds...)
+}
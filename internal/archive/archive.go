@@ -0,0 +1,280 @@
+// archive/archive.go
+// Read-only access to audio files stored inside zip and 7z archives.
+//
+// Types:
+//   - Entry: a regular file inside an archive
+//   - Reader: an open archive
+//
+// Functions:
+//   - IsArchive: reports whether a path names a supported archive
+//   - Open: opens an archive for listing and reading entries
+//   - Extract: copies one entry into the on-disk cache and returns its path;
+//     the cache is kept under CacheLimit
+//   - ReadFile: reads a small entry, e.g. a cover image, into memory
+
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// appCacheDirName is the directory created under the OS cache directory.
+const appCacheDirName = "StellePlayer"
+
+// CacheLimit bounds the total size of the extracted entries, in bytes. When
+// an extraction takes the cache past it, the least recently used copies are
+// removed.
+var CacheLimit int64 = 2 << 30
+
+// staleExtractAge is how old an unfinished extraction must be before it is
+// taken for the leftover of a crash and removed.
+const staleExtractAge = 24 * time.Hour
+
+// Extensions lists the supported archive formats.
+var Extensions = []string{".zip", ".7z"}
+
+// IsArchive reports whether p names a supported archive.
+func IsArchive(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry is a regular file inside an archive. Name uses forward slashes.
+type Entry struct {
+	Name string
+	Size int64
+}
+
+// Reader is an open zip or 7z archive.
+type Reader struct {
+	zip     *zip.ReadCloser
+	sz      *sevenzip.ReadCloser
+	entries []Entry
+}
+
+// Open opens the archive at p.
+func Open(p string) (*Reader, error) {
+	r := &Reader{}
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".zip":
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			return nil, err
+		}
+		r.zip = zr
+		for _, f := range zr.File {
+			if f.Mode().IsRegular() {
+				r.entries = append(r.entries, Entry{Name: cleanName(f.Name), Size: int64(f.UncompressedSize64)})
+			}
+		}
+	case ".7z":
+		sr, err := sevenzip.OpenReader(p)
+		if err != nil {
+			return nil, err
+		}
+		r.sz = sr
+		for _, f := range sr.File {
+			if f.FileInfo().Mode().IsRegular() {
+				r.entries = append(r.entries, Entry{Name: cleanName(f.Name), Size: int64(f.UncompressedSize)})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive: %s", filepath.Base(p))
+	}
+	return r, nil
+}
+
+// cleanName normalises an entry name to forward slashes; some 7z writers
+// store Windows separators.
+func cleanName(name string) string {
+	return path.Clean(strings.ReplaceAll(name, `\`, "/"))
+}
+
+// Entries lists the regular files in archive order.
+func (r *Reader) Entries() []Entry {
+	return r.entries
+}
+
+// Open returns a stream of the named entry's contents.
+func (r *Reader) Open(name string) (io.ReadCloser, error) {
+	if r.zip != nil {
+		for _, f := range r.zip.File {
+			if cleanName(f.Name) == name {
+				return f.Open()
+			}
+		}
+	} else {
+		for _, f := range r.sz.File {
+			if cleanName(f.Name) == name {
+				return f.Open()
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: not in archive", name)
+}
+
+// Close releases the archive.
+func (r *Reader) Close() error {
+	if r.zip != nil {
+		return r.zip.Close()
+	}
+	return r.sz.Close()
+}
+
+// ReadFile reads a whole entry into memory. Entries larger than limit bytes
+// are refused, so a stray video doesn't end up in RAM.
+func ReadFile(archivePath, name string, limit int64) ([]byte, error) {
+	r, err := Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rc, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: larger than %d bytes", name, limit)
+	}
+	return data, nil
+}
+
+// CacheDir returns the directory extracted entries are kept in.
+func CacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, appCacheDirName, "archives")
+}
+
+// Extract copies one entry into the cache and returns the path of the copy,
+// which keeps the entry's extension so tools can tell the format. Entries
+// are extracted once per archive version; later calls reuse the copy and
+// mark it as recently used.
+func Extract(archivePath, name string) (string, error) {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%s", abs, info.Size(), info.ModTime().UnixNano(), name)))
+	dir := CacheDir()
+	dest := filepath.Join(dir, hex.EncodeToString(sum[:])+strings.ToLower(path.Ext(name)))
+	if _, err := os.Stat(dest); err == nil {
+		now := time.Now()
+		os.Chtimes(dest, now, now)
+		return dest, nil
+	}
+
+	r, err := Open(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	rc, err := r.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, ".extract-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("extract %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+	pruneCache(dir, dest)
+	return dest, nil
+}
+
+// pruneCache removes the least recently used copies in dir until the cache
+// fits CacheLimit, along with extractions a crash left unfinished. keep, the
+// copy just made, always stays. Copies that can't be removed, e.g. because
+// they are playing on Windows, are left for next time.
+func pruneCache(dir, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var copies []cached
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if strings.HasPrefix(entry.Name(), ".extract-") {
+			if time.Since(info.ModTime()) > staleExtractAge {
+				os.Remove(path)
+			}
+			continue
+		}
+		copies = append(copies, cached{path: path, size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(copies, func(a, b int) bool {
+		return copies[a].used.Before(copies[b].used)
+	})
+	for _, c := range copies {
+		if total <= CacheLimit {
+			break
+		}
+		if c.path == keep {
+			continue
+		}
+		if os.Remove(c.path) == nil {
+			total -= c.size
+		}
+	}
+}
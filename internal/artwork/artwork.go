@@ -0,0 +1,296 @@
+// artwork/artwork.go
+// Cover art decoding, terminal graphics detection and render caching.
+//
+// Types:
+//   - Protocol: graphics protocol used to draw images in the terminal
+//   - Rendered: a cover image rendered for a fixed cell area
+//   - Cache: per-album cache of decoded images and renders, holding the
+//     most recently used albums
+//
+// Functions:
+//   - DetectProtocol, ParseProtocol: choose the graphics protocol
+//   - Decode: decodes JPEG, PNG or GIF data into an image
+//   - NewCache: creates an empty cache
+
+package artwork
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Protocol is the terminal graphics protocol used to draw cover art.
+type Protocol int
+
+const (
+	ProtocolNone Protocol = iota
+	ProtocolHalfBlocks
+	ProtocolKitty
+	ProtocolSixel
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolHalfBlocks:
+		return "halfblocks"
+	case ProtocolKitty:
+		return "kitty"
+	case ProtocolSixel:
+		return "sixel"
+	}
+	return "none"
+}
+
+// ParseProtocol maps a user-supplied name to a Protocol.
+func ParseProtocol(name string) (Protocol, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "kitty":
+		return ProtocolKitty, true
+	case "sixel":
+		return ProtocolSixel, true
+	case "halfblocks", "blocks", "ansi":
+		return ProtocolHalfBlocks, true
+	case "none", "off":
+		return ProtocolNone, true
+	}
+	return ProtocolNone, false
+}
+
+// DetectProtocol picks the best protocol the terminal is known to support.
+// STELLE_GRAPHICS overrides detection; half blocks are the universal fallback.
+func DetectProtocol() Protocol {
+	if p, ok := ParseProtocol(os.Getenv("STELLE_GRAPHICS")); ok {
+		return p
+	}
+
+	// Multiplexers swallow graphics escapes unless passthrough is configured.
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		return ProtocolHalfBlocks
+	}
+
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+
+	if term == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "" || termProgram == "ghostty" {
+		return ProtocolKitty
+	}
+
+	switch termProgram {
+	case "WezTerm", "iTerm.app", "mintty":
+		return ProtocolSixel
+	}
+	for _, name := range []string{"foot", "mlterm", "sixel", "contour"} {
+		if strings.Contains(term, name) {
+			return ProtocolSixel
+		}
+	}
+
+	return ProtocolHalfBlocks
+}
+
+// Decode decodes JPEG, PNG or GIF image data.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode cover art: %w", err)
+	}
+	return img, nil
+}
+
+// Rendered is a cover image rendered for a Cols x Rows cell area.
+type Rendered struct {
+	// Block is the text placed in the layout: Rows lines of Cols cells.
+	Block string
+	Cols  int
+	Rows  int
+
+	protocol Protocol
+	overlays []string
+}
+
+// Overlays returns, for each row of the block, an escape sequence the caller
+// appends to that screen line after layout. row and col are the 1-based
+// screen position of the block's top-left cell.
+//
+// Kitty transmits the image alongside the first row; the placeholders in
+// Block do the rest. Sixel pixels are erased whenever the terminal repaints
+// a line under them, so every row carries its own slice of the image and
+// redraws it whenever that line is repainted.
+func (r Rendered) Overlays(row, col int) []string {
+	if r.protocol != ProtocolSixel {
+		return r.overlays
+	}
+
+	positioned := make([]string, len(r.overlays))
+	for i, slice := range r.overlays {
+		if slice != "" {
+			positioned[i] = fmt.Sprintf("\x1b7\x1b[%d;%dH%s\x1b8", row+i, col, slice)
+		}
+	}
+	return positioned
+}
+
+type renderKey struct {
+	source   string
+	protocol Protocol
+	cols     int
+	rows     int
+}
+
+// maxCachedSize bounds the width and height of cached images. Larger covers
+// are downscaled on the way in; no panel shows more pixels than this.
+const maxCachedSize = 1024
+
+// maxCachedSources is how many art sources the cache holds. Past that the
+// least recently used one is dropped along with its renders.
+const maxCachedSources = 32
+
+// Cache keeps decoded cover images and their renders keyed by art source,
+// so every track of an album shares one decode and one resize.
+type Cache struct {
+	mu      sync.Mutex
+	images  map[string]image.Image
+	recent  []string // sources in images, least recently used first
+	renders map[renderKey]Rendered
+	ids     map[string]uint32
+	owners  map[uint32]string
+	nextID  uint32
+}
+
+// NewCache creates an empty cache.
+func NewCache() *Cache {
+	return &Cache{
+		images:  make(map[string]image.Image),
+		renders: make(map[renderKey]Rendered),
+		ids:     make(map[string]uint32),
+		owners:  make(map[uint32]string),
+	}
+}
+
+// Image returns the decoded image for a source, if cached. A cached nil image
+// records that the source has no usable art.
+func (c *Cache) Image(source string) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	img, ok := c.images[source]
+	if ok {
+		c.touch(source)
+	}
+	return img, ok
+}
+
+// StoreImage caches the decoded image for a source, downscaled to
+// maxCachedSize.
+func (c *Cache) StoreImage(source string, img image.Image) {
+	if img != nil {
+		b := img.Bounds()
+		if b.Dx() > maxCachedSize || b.Dy() > maxCachedSize {
+			w, h := fitSize(b.Dx(), b.Dy(), maxCachedSize, maxCachedSize)
+			img = resize(img, w, h)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images[source] = img
+	c.touch(source)
+	for len(c.recent) > maxCachedSources {
+		c.evict(c.recent[0])
+	}
+}
+
+// touch marks source as the most recently used.
+func (c *Cache) touch(source string) {
+	for i, s := range c.recent {
+		if s == source {
+			c.recent = append(c.recent[:i], c.recent[i+1:]...)
+			break
+		}
+	}
+	c.recent = append(c.recent, source)
+}
+
+// evict drops source's image and renders. Its kitty ID is kept, as the
+// terminal may still hold the image under it.
+func (c *Cache) evict(source string) {
+	delete(c.images, source)
+	for i, s := range c.recent {
+		if s == source {
+			c.recent = append(c.recent[:i], c.recent[i+1:]...)
+			break
+		}
+	}
+	for key := range c.renders {
+		if key.source == source {
+			delete(c.renders, key)
+		}
+	}
+}
+
+// Render returns the image for source rendered into a cols x rows cell area,
+// reusing a previous render of the same size when available.
+func (c *Cache) Render(source string, protocol Protocol, cols, rows int) (Rendered, bool) {
+	if protocol == ProtocolNone || cols <= 0 || rows <= 0 {
+		return Rendered{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := renderKey{source: source, protocol: protocol, cols: cols, rows: rows}
+	if r, ok := c.renders[key]; ok {
+		c.touch(source)
+		return r, true
+	}
+
+	img, ok := c.images[source]
+	if !ok || img == nil {
+		return Rendered{}, false
+	}
+	c.touch(source)
+
+	var r Rendered
+	switch protocol {
+	case ProtocolKitty:
+		r = renderKitty(img, c.imageID(source), cols, rows)
+	case ProtocolSixel:
+		r = renderSixel(img, cols, rows)
+	default:
+		r = renderHalfBlocks(img, cols, rows)
+	}
+	r.protocol = protocol
+	c.renders[key] = r
+	return r, true
+}
+
+// imageID assigns kitty image IDs. They are kept below 256 so the ID fits in
+// a 256-color foreground, which is how placeholders reference their image.
+// When an ID is recycled the previous owner's kitty renders are dropped,
+// since the terminal no longer holds that image.
+func (c *Cache) imageID(source string) uint32 {
+	if id, ok := c.ids[source]; ok {
+		return id
+	}
+
+	c.nextID = c.nextID%255 + 1
+	id := c.nextID
+	if previous, ok := c.owners[id]; ok {
+		delete(c.ids, previous)
+		for key := range c.renders {
+			if key.source == previous && key.protocol == ProtocolKitty {
+				delete(c.renders, key)
+			}
+		}
+	}
+
+	c.ids[source] = id
+	c.owners[id] = source
+	return id
+}
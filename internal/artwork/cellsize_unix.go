@@ -0,0 +1,19 @@
+//go:build !windows
+
+package artwork
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cellSize reports the terminal cell size in pixels, falling back to a
+// common 10x20 when the terminal does not fill in the pixel dimensions.
+func cellSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 10, 20
+	}
+	return int(ws.Xpixel) / int(ws.Col), int(ws.Ypixel) / int(ws.Row)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package artwork
+
+// cellSize reports the terminal cell size in pixels. The Windows console API
+// does not expose it, so a common 10x20 is assumed.
+func cellSize() (int, int) {
+	return 10, 20
+}
@@ -0,0 +1,50 @@
+package artwork
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderHalfBlocks draws the image with "▀" cells: the foreground colors the
+// upper pixel and the background the lower one, giving two pixels per cell.
+// lipgloss downsamples the colors on terminals without true color.
+func renderHalfBlocks(img image.Image, cols, rows int) Rendered {
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), cols, rows*2)
+	h -= h % 2
+	if h == 0 {
+		h = 2
+	}
+	scaled := resize(img, w, h)
+
+	padLeft := (cols - w) / 2
+	padTop := (rows - h/2) / 2
+	blank := strings.Repeat(" ", cols)
+
+	lines := make([]string, 0, rows)
+	for i := 0; i < padTop; i++ {
+		lines = append(lines, blank)
+	}
+	for y := 0; y < h; y += 2 {
+		var line strings.Builder
+		line.WriteString(strings.Repeat(" ", padLeft))
+		for x := 0; x < w; x++ {
+			top := scaled.RGBAAt(x, y)
+			bottom := scaled.RGBAAt(x, y+1)
+			style := lipgloss.NewStyle().
+				Foreground(lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", top.R, top.G, top.B))).
+				Background(lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", bottom.R, bottom.G, bottom.B)))
+			line.WriteString(style.Render("▀"))
+		}
+		line.WriteString(strings.Repeat(" ", cols-w-padLeft))
+		lines = append(lines, line.String())
+	}
+	for len(lines) < rows {
+		lines = append(lines, blank)
+	}
+
+	return Rendered{Block: strings.Join(lines, "\n"), Cols: cols, Rows: rows}
+}
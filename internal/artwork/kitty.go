@@ -0,0 +1,92 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// kittyPlaceholder is the Unicode placeholder character from the kitty
+// graphics protocol. Each cell of a virtual placement is drawn as this
+// character, with the image ID encoded in the foreground color, so the image
+// flows through the layout like ordinary text.
+const kittyPlaceholder = "\U0010EEEE"
+
+// kittyDiacritics encode row and column numbers for placeholder cells, as
+// listed in kitty's rowcolumn-diacritics.txt. Only the first cell of each row
+// carries them; kitty infers the column of the following cells.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F, 0x0483, 0x0484,
+}
+
+// kittyChunkSize is the maximum base64 payload per escape sequence.
+const kittyChunkSize = 4096
+
+// kittyMaxPixels bounds the transmitted image; kitty scales it into the
+// placement, so sending the full-size cover would only cost bandwidth.
+const kittyMaxPixels = 400
+
+func renderKitty(img image.Image, id uint32, cols, rows int) Rendered {
+	if rows > len(kittyDiacritics) {
+		rows = len(kittyDiacritics)
+	}
+
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), kittyMaxPixels, kittyMaxPixels)
+	if w > b.Dx() || h > b.Dy() {
+		w, h = b.Dx(), b.Dy()
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, resize(img, w, h)); err != nil {
+		return renderHalfBlocks(img, cols, rows)
+	}
+
+	lines := make([]string, rows)
+	for row := range lines {
+		var line strings.Builder
+		fmt.Fprintf(&line, "\x1b[38;5;%dm", id)
+		line.WriteString(kittyPlaceholder)
+		line.WriteRune(kittyDiacritics[row])
+		line.WriteRune(kittyDiacritics[0])
+		line.WriteString(strings.Repeat(kittyPlaceholder, cols-1))
+		line.WriteString("\x1b[39m")
+		lines[row] = line.String()
+	}
+
+	return Rendered{
+		Block:    strings.Join(lines, "\n"),
+		Cols:     cols,
+		Rows:     rows,
+		overlays: []string{kittyTransmit(id, encoded.Bytes(), cols, rows)},
+	}
+}
+
+// kittyTransmit builds the escape sequences that upload a PNG and create a
+// virtual placement (U=1) of cols x rows cells for the placeholders.
+func kittyTransmit(id uint32, data []byte, cols, rows int) string {
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	var out strings.Builder
+	for i := 0; i < len(payload); i += kittyChunkSize {
+		end := i + kittyChunkSize
+		more := 1
+		if end >= len(payload) {
+			end = len(payload)
+			more = 0
+		}
+		if i == 0 {
+			fmt.Fprintf(&out, "\x1b_Ga=T,U=1,q=2,f=100,i=%d,c=%d,r=%d,m=%d;%s\x1b\\",
+				id, cols, rows, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	return out.String()
+}
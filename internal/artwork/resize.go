@@ -0,0 +1,69 @@
+package artwork
+
+import (
+	"image"
+	"image/color"
+)
+
+// fitSize scales srcW x srcH down or up to fit inside maxW x maxH while
+// keeping the aspect ratio.
+func fitSize(srcW, srcH, maxW, maxH int) (int, int) {
+	if srcW <= 0 || srcH <= 0 {
+		return maxW, maxH
+	}
+	w, h := maxW, srcH*maxW/srcW
+	if h > maxH {
+		w, h = srcW*maxH/srcH, maxH
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// resize scales img to exactly w x h using box sampling, which averages every
+// source pixel under a target pixel. Cover art is almost always downscaled, so
+// this keeps small renders from looking noisy without pulling in a library.
+func resize(img image.Image, w, h int) *image.RGBA {
+	src := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	srcW, srcH := src.Dx(), src.Dy()
+
+	for y := 0; y < h; y++ {
+		y0 := src.Min.Y + y*srcH/h
+		y1 := src.Min.Y + (y+1)*srcH/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := src.Min.X + x*srcW/w
+			x1 := src.Min.X + (x+1)*srcW/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
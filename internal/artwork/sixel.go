@@ -0,0 +1,122 @@
+package artwork
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"sort"
+	"strings"
+)
+
+// renderSixel encodes the image as one sixel slice per cell row. The layout
+// block is left blank and each slice is drawn over its row through Overlays,
+// so a repainted line only costs that line's pixels.
+func renderSixel(img image.Image, cols, rows int) Rendered {
+	cellW, cellH := cellSize()
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), cols*cellW, rows*cellH)
+
+	// Quantize once for the whole image. Dithering is skipped on purpose:
+	// the noise it adds defeats the run-length encoding.
+	indexed := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+	draw.Draw(indexed, indexed.Bounds(), resize(img, w, h), image.Point{}, draw.Src)
+
+	blank := strings.Repeat(" ", cols)
+	lines := make([]string, rows)
+	slices := make([]string, rows)
+	for i := range lines {
+		lines[i] = blank
+		top := i * cellH
+		if top >= h {
+			continue
+		}
+		bottom := top + cellH
+		if bottom > h {
+			bottom = h
+		}
+		slices[i] = encodeSixel(indexed.SubImage(image.Rect(0, top, w, bottom)).(*image.Paletted))
+	}
+
+	return Rendered{
+		Block:    strings.Join(lines, "\n"),
+		Cols:     cols,
+		Rows:     rows,
+		overlays: slices,
+	}
+}
+
+// encodeSixel emits an indexed image band by band, one pass per color, with
+// run-length encoding.
+func encodeSixel(indexed *image.Paletted) string {
+	bounds := indexed.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var out strings.Builder
+	out.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&out, "\"1;1;%d;%d", w, h)
+
+	used := make(map[uint8]bool)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			used[indexed.ColorIndexAt(x, y)] = true
+		}
+	}
+	for idx := range used {
+		r, g, b, _ := indexed.Palette[idx].RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", idx, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	for y0 := 0; y0 < h; y0 += 6 {
+		bandColors := make(map[uint8]bool)
+		for y := y0; y < y0+6 && y < h; y++ {
+			for x := 0; x < w; x++ {
+				bandColors[indexed.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y)] = true
+			}
+		}
+		colors := make([]int, 0, len(bandColors))
+		for idx := range bandColors {
+			colors = append(colors, int(idx))
+		}
+		sort.Ints(colors)
+
+		for i, idx := range colors {
+			if i > 0 {
+				out.WriteByte('$')
+			}
+			fmt.Fprintf(&out, "#%d", idx)
+
+			run, last := 0, byte(0)
+			for x := 0; x < w; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y0+dy < h; dy++ {
+					if int(indexed.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y0+dy)) == idx {
+						bits |= 1 << dy
+					}
+				}
+				ch := 63 + bits
+				if run > 0 && ch != last {
+					writeSixelRun(&out, last, run)
+					run = 0
+				}
+				last = ch
+				run++
+			}
+			writeSixelRun(&out, last, run)
+		}
+		out.WriteByte('-')
+	}
+
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+func writeSixelRun(out *strings.Builder, ch byte, run int) {
+	if run > 3 {
+		fmt.Fprintf(out, "!%d%c", run, ch)
+		return
+	}
+	for i := 0; i < run; i++ {
+		out.WriteByte(ch)
+	}
+}
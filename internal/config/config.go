@@ -0,0 +1,198 @@
+// config/config.go
+// User configuration loaded from a JSON file.
+//
+// Types:
+//   - Config: all user-tunable settings
+//   - LyricsSource: one provider of the lyrics chain
+//
+// Functions:
+//   - DefaultPath: returns the per-user config file location
+//   - LibraryDir, OverridesPath: per-library state locations
+//   - PositionsPath: location of the saved resume positions
+//   - Load: reads a config file, falling back to defaults when it is missing
+
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// appDirName is the directory created under the OS config directory.
+const appDirName = "StellePlayer"
+
+// Config holds the user settings. Zero values mean "use the default".
+type Config struct {
+	// InferFromPath fills missing tags from the file path using PathPatterns.
+	InferFromPath bool `json:"inferFromPath"`
+
+	// PathPatterns are tried in order against the end of each file's path
+	// inside its library root, e.g. "{artist}/{album}/{track} - {title}"
+	// or "{artist} - {title}".
+	PathPatterns []string `json:"pathPatterns"`
+
+	// LibraryRoots are music directories scanned in addition to any given
+	// with -sd. When -sd is absent they replace the folder picker.
+	LibraryRoots []string `json:"libraryRoots"`
+
+	// Exclude holds gitignore-style patterns applied below every root, e.g.
+	// "Samples/" or "*stem*".
+	Exclude []string `json:"exclude"`
+
+	// FollowSymlinks descends into symlinked folders and files. Loops are
+	// detected and a track reachable through several links is listed once.
+	FollowSymlinks bool `json:"followSymlinks"`
+
+	// ScanArchives lists the audio files inside .zip and .7z archives as
+	// songs. Entries are extracted to the cache when played.
+	ScanArchives bool `json:"scanArchives"`
+
+	// MinDuration skips tracks shorter than this many seconds.
+	MinDuration float64 `json:"minDuration"`
+
+	// ResumeMinDuration is the shortest track, in seconds, whose position
+	// is remembered and resumed. Zero uses DefaultResumeMinDuration;
+	// negative turns resuming off.
+	ResumeMinDuration float64 `json:"resumeMinDuration"`
+
+	// LyricsSources is the chain of lyrics providers, asked in order.
+	// Providers left out are not used. Empty uses DefaultLyricsSources.
+	LyricsSources []LyricsSource `json:"lyricsSources"`
+
+	// Extensions replaces the list of scanned file extensions, e.g. to add
+	// ".wv", ".ape", ".mka" or ".dsf". Empty keeps the built-in list.
+	Extensions []string `json:"extensions"`
+}
+
+// DefaultResumeMinDuration covers audiobooks, podcasts and long mixes but
+// not ordinary songs.
+const DefaultResumeMinDuration = 10 * 60
+
+// LyricsSource configures one lyrics provider. In the config file a bare
+// name such as "local" stands for {"type": "local"}.
+type LyricsSource struct {
+	// Type is "local" (the lyrics folder), "sidecar" (a lyrics file next to
+	// the audio file), "embedded" (the audio file's tags), "lrclib" (also
+	// "online") or "http" (a generic JSON service).
+	Type string `json:"type"`
+
+	// Name labels an http provider; it defaults to the host.
+	Name string `json:"name,omitempty"`
+
+	// URL is an lrclib server's base URL, empty for the public one, or an
+	// http provider's search URL with {artist}, {title}, {album} and
+	// {duration} placeholders.
+	URL string `json:"url,omitempty"`
+
+	// GetURL fetches one http result by {id}.
+	GetURL string `json:"getUrl,omitempty"`
+
+	// Results is the dot path to the results array of an http response.
+	Results string `json:"results,omitempty"`
+
+	// Fields maps id, artist, title, album, duration, synced, plain and
+	// instrumental to dot paths in an http result. Missing ones use
+	// LRCLIB's names.
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// Headers are sent with every http request, e.g. an API key.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// UnmarshalJSON accepts a bare type name as well as an object.
+func (s *LyricsSource) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = LyricsSource{Type: name}
+		return nil
+	}
+	type plain LyricsSource
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// DefaultLyricsSources looks in the lyrics folder, next to the file and in
+// its tags, and only then online.
+var DefaultLyricsSources = []LyricsSource{
+	{Type: "local"},
+	{Type: "sidecar"},
+	{Type: "embedded"},
+	{Type: "lrclib"},
+}
+
+// Dir returns the per-user directory holding the config and state files.
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, appDirName), nil
+}
+
+// DefaultPath returns the location of config.json in Dir.
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LibraryDir returns the directory holding state for the library rooted at
+// root. State lives with the user rather than in the library so read-only
+// shares work.
+func LibraryDir(root string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(dir, "libraries", hex.EncodeToString(sum[:8])), nil
+}
+
+// OverridesPath returns the metadata overrides file for a library.
+func OverridesPath(root string) (string, error) {
+	dir, err := LibraryDir(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "overrides.json"), nil
+}
+
+// PositionsPath returns the file holding resume positions. Positions are
+// keyed by absolute path, so one file serves every library.
+func PositionsPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "positions.json"), nil
+}
+
+// Load reads the config at path. A missing file is not an error and yields
+// the defaults.
+func Load(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,306 @@
+package lyrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// FromEmbedded turns the text of an embedded lyrics tag (ID3 USLT, Vorbis
+// LYRICS or UNSYNCEDLYRICS, MP4 ©lyr) into lyrics. Taggers often store LRC
+// there, which comes back synced; anything else is plain.
+func FromEmbedded(text string) (Lyrics, bool) {
+	if strings.TrimSpace(text) == "" {
+		return Lyrics{}, false
+	}
+	doc := ParseDocument(text)
+	if doc.Instrumental {
+		return Lyrics{Instrumental: true, Loaded: true}, true
+	}
+	if len(doc.Lines) > 0 {
+		return fromDocument(doc), true
+	}
+	ly := ParsePlain(text)
+	return ly, ly.Found()
+}
+
+// Embedded reads lyrics stored in the audio file's tags. Synced lyrics (an
+// ID3 SYLT frame) beat the text tag. IDs are file paths; Get only reads
+// SYLT frames, since the text tag comes from the library scan.
+type Embedded struct{}
+
+func NewEmbedded() *Embedded {
+	return &Embedded{}
+}
+
+func (p *Embedded) Name() string { return "embedded" }
+
+func (p *Embedded) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	if q.FilePath != "" {
+		if lines, err := ReadSYLT(q.FilePath); err == nil && len(lines) > 0 {
+			return []Candidate{{Provider: p.Name(), ID: q.FilePath, Confidence: 1, Lyrics: Lyrics{Lines: lines, Loaded: true}}}, nil
+		}
+	}
+	if ly, found := FromEmbedded(q.Embedded); found {
+		return []Candidate{{Provider: p.Name(), ID: q.FilePath, Confidence: 1, Lyrics: ly}}, nil
+	}
+	return nil, nil
+}
+
+func (p *Embedded) Get(ctx context.Context, id string) (Candidate, error) {
+	lines, err := ReadSYLT(id)
+	if err != nil {
+		return Candidate{}, err
+	}
+	if len(lines) == 0 {
+		return Candidate{}, errNotFound
+	}
+	return Candidate{Provider: p.Name(), ID: id, Confidence: 1, Lyrics: Lyrics{Lines: lines, Loaded: true}}, nil
+}
+
+// errNoID3 is returned for files that don't start with an ID3v2 tag.
+var errNoID3 = errors.New("no ID3v2 tag")
+
+// ReadSYLT returns the synchronised lyrics (SYLT frame) of the ID3v2 tag at
+// the start of a file, which ffprobe doesn't report. Entries starting with
+// a line break begin a new line; the entries in between become its words,
+// so karaoke-style frames highlight word by word. Only millisecond
+// timestamps are supported.
+func ReadSYLT(filePath string) ([]Line, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:3]) != "ID3" {
+		return nil, errNoID3
+	}
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 {
+		return nil, errNoID3
+	}
+	tag := make([]byte, syncsafe(header[6:10]))
+	if _, err := io.ReadFull(f, tag); err != nil {
+		return nil, err
+	}
+	if flags&0x80 != 0 && version < 4 {
+		// Before v2.4 unsynchronisation applies to the whole tag.
+		tag = bytes.ReplaceAll(tag, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	if flags&0x40 != 0 && version >= 3 && len(tag) >= 4 {
+		size := int(binary.BigEndian.Uint32(tag[:4])) + 4
+		if version == 4 {
+			size = syncsafe(tag[:4])
+		}
+		if size > len(tag) {
+			return nil, errNoID3
+		}
+		tag = tag[size:]
+	}
+
+	idLen, headerLen := 4, 10
+	frameID := "SYLT"
+	if version == 2 {
+		idLen, headerLen, frameID = 3, 6, "SLT"
+	}
+	for len(tag) >= headerLen && tag[0] != 0 {
+		id := string(tag[:idLen])
+		var size int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			size = int(tag[3])<<16 | int(tag[4])<<8 | int(tag[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(tag[4:8]))
+			frameFlags = binary.BigEndian.Uint16(tag[8:10])
+		default:
+			size = syncsafe(tag[4:8])
+			frameFlags = binary.BigEndian.Uint16(tag[8:10])
+		}
+		if size < 0 || headerLen+size > len(tag) {
+			break
+		}
+		body := tag[headerLen : headerLen+size]
+		tag = tag[headerLen+size:]
+		if id != frameID {
+			continue
+		}
+		body, ok := frameData(body, version, frameFlags, flags&0x80 != 0)
+		if !ok {
+			continue
+		}
+		if lines := parseSYLT(body); len(lines) > 0 {
+			return lines, nil
+		}
+	}
+	return nil, nil
+}
+
+// frameData returns a frame's data without the bytes its flags add before
+// it, undoing v2.4 unsynchronisation, which applies per frame; tagUnsync is
+// the tag header's flag, which in v2.4 stands for every frame's. Compressed
+// and encrypted frames can't be read and report false.
+func frameData(body []byte, version byte, frameFlags uint16, tagUnsync bool) ([]byte, bool) {
+	var skip int
+	switch version {
+	case 3:
+		if frameFlags&0x0080 != 0 || frameFlags&0x0040 != 0 {
+			return nil, false
+		}
+		if frameFlags&0x0020 != 0 {
+			// Group identifier.
+			skip++
+		}
+	case 4:
+		if frameFlags&0x0008 != 0 || frameFlags&0x0004 != 0 {
+			return nil, false
+		}
+		if frameFlags&0x0040 != 0 {
+			// Group identifier.
+			skip++
+		}
+		if frameFlags&0x0001 != 0 {
+			// Data length indicator.
+			skip += 4
+		}
+	}
+	if skip > len(body) {
+		return nil, false
+	}
+	body = body[skip:]
+	if version == 4 && (frameFlags&0x0002 != 0 || tagUnsync) {
+		body = bytes.ReplaceAll(body, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	return body, true
+}
+
+// syncsafe decodes a 28-bit integer stored 7 bits per byte.
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// parseSYLT decodes a SYLT frame body: encoding, language, timestamp
+// format, content type and descriptor, then text and timestamp pairs.
+func parseSYLT(body []byte) []Line {
+	if len(body) < 6 {
+		return nil
+	}
+	enc, format := body[0], body[4]
+	if format != 2 {
+		// MPEG frame timestamps need the frame rate; rare in practice.
+		return nil
+	}
+	_, rest := readID3String(body[6:], enc)
+
+	type entry struct {
+		text string
+		at   float64
+	}
+	var entries []entry
+	for len(rest) > 0 {
+		var text string
+		text, rest = readID3String(rest, enc)
+		if len(rest) < 4 {
+			break
+		}
+		ms := binary.BigEndian.Uint32(rest[:4])
+		rest = rest[4:]
+		entries = append(entries, entry{text: text, at: float64(ms) / 1000})
+	}
+
+	breaks := false
+	for _, e := range entries {
+		if strings.HasPrefix(e.text, "\n") || strings.HasPrefix(e.text, "\r") {
+			breaks = true
+			break
+		}
+	}
+
+	var lines []Line
+	for _, e := range entries {
+		text := strings.TrimLeft(e.text, "\r\n")
+		if !breaks || len(lines) == 0 || text != e.text {
+			lines = append(lines, Line{Time: e.at})
+		}
+		line := &lines[len(lines)-1]
+		if breaks {
+			if len(line.Words) > 0 {
+				line.Words[len(line.Words)-1].End = e.at
+			}
+			line.Words = append(line.Words, Word{Start: e.at, Text: text})
+		}
+		line.Text += text
+	}
+
+	for i := range lines {
+		lines[i].Text = strings.TrimSpace(lines[i].Text)
+		if len(lines[i].Words) < 2 {
+			// A single segment is just the line.
+			lines[i].Words = nil
+		}
+	}
+	return lines
+}
+
+// readID3String reads one terminated string in the given ID3 text encoding
+// and returns it with the bytes after the terminator.
+func readID3String(b []byte, enc byte) (string, []byte) {
+	switch enc {
+	case 1, 2:
+		end := 0
+		for end+1 < len(b) && (b[end] != 0 || b[end+1] != 0) {
+			end += 2
+		}
+		s := decodeUTF16(b[:end], enc == 2)
+		if end+2 <= len(b) {
+			return s, b[end+2:]
+		}
+		return s, nil
+	default:
+		end := bytes.IndexByte(b, 0)
+		if end < 0 {
+			end = len(b)
+		}
+		raw := b[:end]
+		rest := b[end:]
+		if len(rest) > 0 {
+			rest = rest[1:]
+		}
+		if enc == 3 {
+			return string(raw), rest
+		}
+		runes := make([]rune, len(raw))
+		for i, c := range raw {
+			runes[i] = rune(c)
+		}
+		return string(runes), rest
+	}
+}
+
+// decodeUTF16 decodes UTF-16 text, honouring a byte order mark.
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFF && b[1] == 0xFE:
+			bigEndian, b = false, b[2:]
+		case b[0] == 0xFE && b[1] == 0xFF:
+			bigEndian, b = true, b[2:]
+		}
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = binary.BigEndian.Uint16(b[2*i:])
+		} else {
+			units[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
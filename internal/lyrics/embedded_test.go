@@ -0,0 +1,290 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// syltEntry is a text and millisecond pair of a SYLT frame.
+type syltEntry struct {
+	text string
+	ms   uint32
+}
+
+// encodeID3String writes s terminated in the given ID3 text encoding. For
+// UTF-16 (1), bom is written first; nil leaves it out.
+func encodeID3String(s string, enc byte, bom []byte) []byte {
+	switch enc {
+	case 1, 2:
+		order := binary.AppendByteOrder(binary.BigEndian)
+		if enc == 1 && !bytes.Equal(bom, []byte{0xFE, 0xFF}) {
+			order = binary.LittleEndian
+		}
+		b := append([]byte(nil), bom...)
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = order.AppendUint16(b, u)
+		}
+		return append(b, 0, 0)
+	case 3:
+		return append([]byte(s), 0)
+	default:
+		b := make([]byte, 0, len(s)+1)
+		for _, r := range s {
+			b = append(b, byte(r))
+		}
+		return append(b, 0)
+	}
+}
+
+// syltBody builds a SYLT frame body with millisecond timestamps.
+func syltBody(enc byte, bom []byte, entries ...syltEntry) []byte {
+	b := []byte{enc, 'e', 'n', 'g', 2, 1}
+	b = append(b, encodeID3String("", enc, bom)...)
+	for _, e := range entries {
+		b = append(b, encodeID3String(e.text, enc, bom)...)
+		b = binary.BigEndian.AppendUint32(b, e.ms)
+	}
+	return b
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// id3Frame builds a frame of the given tag version around body as stored.
+func id3Frame(version byte, id string, flags uint16, body []byte) []byte {
+	b := []byte(id)
+	switch version {
+	case 2:
+		b = append(b, byte(len(body)>>16), byte(len(body)>>8), byte(len(body)))
+		return append(b, body...)
+	case 3:
+		b = binary.BigEndian.AppendUint32(b, uint32(len(body)))
+	default:
+		b = append(b, syncsafeBytes(len(body))...)
+	}
+	b = binary.BigEndian.AppendUint16(b, flags)
+	return append(b, body...)
+}
+
+// unsynchronise inserts a zero after every 0xFF, which reading undoes.
+func unsynchronise(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF}, []byte{0xFF, 0x00})
+}
+
+// id3Tag builds a tag header followed by frames and some padding.
+func id3Tag(version, flags byte, frames ...[]byte) []byte {
+	body := append(bytes.Join(frames, nil), make([]byte, 16)...)
+	b := []byte{'I', 'D', '3', version, 0, flags}
+	b = append(b, syncsafeBytes(len(body))...)
+	return append(b, body...)
+}
+
+func TestReadSYLT(t *testing.T) {
+	entries := []syltEntry{{"First line", 1500}, {"Second line", 65296}}
+	want := []Line{{Time: 1.5, Text: "First line"}, {Time: 65.296, Text: "Second line"}}
+	le, be := []byte{0xFF, 0xFE}, []byte{0xFE, 0xFF}
+	title := id3Frame(3, "TIT2", 0, append([]byte{3}, "Title"...))
+
+	// A v2.4 frame with its data length indicator, unsynchronised.
+	dliBody := syltBody(3, nil, entries...)
+	dliFrame := append(syncsafeBytes(len(dliBody)), unsynchronise(dliBody)...)
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want []Line
+	}{
+		{
+			name: "v2.2",
+			tag:  id3Tag(2, 0, id3Frame(2, "TT2", 0, []byte{0, 'T'}), id3Frame(2, "SLT", 0, syltBody(0, nil, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.3 latin-1",
+			tag:  id3Tag(3, 0, title, id3Frame(3, "SYLT", 0, syltBody(0, nil, syltEntry{"Café", 1500}))),
+			want: []Line{{Time: 1.5, Text: "Café"}},
+		},
+		{
+			name: "v2.3 utf-16 little-endian bom",
+			tag:  id3Tag(3, 0, id3Frame(3, "SYLT", 0, syltBody(1, le, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.3 utf-16 big-endian bom",
+			tag:  id3Tag(3, 0, id3Frame(3, "SYLT", 0, syltBody(1, be, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.3 utf-16 without bom",
+			tag:  id3Tag(3, 0, id3Frame(3, "SYLT", 0, syltBody(1, nil, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.4 utf-16be",
+			tag:  id3Tag(4, 0, id3Frame(4, "SYLT", 0, syltBody(2, nil, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.3 unsynchronised tag",
+			tag: func() []byte {
+				tag := id3Tag(3, 0, id3Frame(3, "SYLT", 0, syltBody(1, le, entries...)))
+				body := unsynchronise(tag[10:])
+				return append(append([]byte{'I', 'D', '3', 3, 0, 0x80}, syncsafeBytes(len(body))...), body...)
+			}(),
+			want: want,
+		},
+		{
+			name: "v2.3 extended header and group",
+			tag: id3Tag(3, 0x40,
+				[]byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0},
+				id3Frame(3, "SYLT", 0x0020, append([]byte{7}, syltBody(0, nil, entries...)...))),
+			want: want,
+		},
+		{
+			name: "v2.3 compressed and encrypted skipped",
+			tag: id3Tag(3, 0,
+				id3Frame(3, "SYLT", 0x0080, []byte{0, 0, 0, 9, 'x', 'y', 'z'}),
+				id3Frame(3, "SYLT", 0x0040, []byte{1, 'x', 'y', 'z'}),
+				id3Frame(3, "SYLT", 0, syltBody(3, nil, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.4 utf-8",
+			tag:  id3Tag(4, 0, id3Frame(4, "SYLT", 0, syltBody(3, nil, entries...))),
+			want: want,
+		},
+		{
+			name: "v2.4 data length indicator",
+			tag:  id3Tag(4, 0, id3Frame(4, "SYLT", 0x0001, append(syncsafeBytes(len(dliBody)), dliBody...))),
+			want: want,
+		},
+		{
+			name: "v2.4 unsynchronised frame with data length indicator",
+			tag:  id3Tag(4, 0, id3Frame(4, "SYLT", 0x0003, dliFrame)),
+			want: want,
+		},
+		{
+			name: "v2.4 unsynchronised tag",
+			tag:  id3Tag(4, 0x80, id3Frame(4, "SYLT", 0, unsynchronise(syltBody(1, le, entries...)))),
+			want: want,
+		},
+		{
+			name: "v2.4 compressed and encrypted skipped",
+			tag: id3Tag(4, 0,
+				id3Frame(4, "SYLT", 0x0009, append(syncsafeBytes(40), 'x', 'y', 'z')),
+				id3Frame(4, "SYLT", 0x0004, []byte{1, 'x', 'y', 'z'}),
+				id3Frame(4, "SYLT", 0x0040, append([]byte{7}, syltBody(3, nil, entries...)...))),
+			want: want,
+		},
+		{
+			name: "only compressed",
+			tag:  id3Tag(4, 0, id3Frame(4, "SYLT", 0x0009, append(syncsafeBytes(40), 'x', 'y', 'z'))),
+		},
+		{
+			name: "mpeg frame timestamps",
+			tag: id3Tag(3, 0, id3Frame(3, "SYLT", 0, func() []byte {
+				b := syltBody(0, nil, entries...)
+				b[4] = 1
+				return b
+			}())),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "song.mp3")
+			if err := os.WriteFile(path, append(tt.tag, 0xFF, 0xFB, 0x90, 0x00), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadSYLT(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if !closeTo(got[i].Time, tt.want[i].Time) || got[i].Text != tt.want[i].Text || got[i].Words != nil {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadSYLTNoTag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "song.mp3")
+	if err := os.WriteFile(path, []byte{0xFF, 0xFB, 0x90, 0x00, 0, 0, 0, 0, 0, 0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSYLT(path); err != errNoID3 {
+		t.Errorf("err = %v, want %v", err, errNoID3)
+	}
+}
+
+func TestParseSYLT(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []syltEntry
+		extra   string // after the entries
+		want    []Line
+	}{
+		{
+			name:    "one entry per line",
+			entries: []syltEntry{{"One", 1000}, {" Two ", 2000}},
+			want:    []Line{{Time: 1, Text: "One"}, {Time: 2, Text: "Two"}},
+		},
+		{
+			name: "words between breaks",
+			entries: []syltEntry{
+				{"One ", 1000}, {"two", 1500},
+				{"\nThree ", 3000}, {"four", 3400},
+			},
+			want: []Line{
+				{Time: 1, Text: "One two", Words: []Word{{Start: 1, End: 1.5, Text: "One "}, {Start: 1.5, Text: "two"}}},
+				{Time: 3, Text: "Three four", Words: []Word{{Start: 3, End: 3.4, Text: "Three "}, {Start: 3.4, Text: "four"}}},
+			},
+		},
+		{
+			name:    "crlf breaks and a single segment",
+			entries: []syltEntry{{"Alone", 1000}, {"\r\nHello ", 2000}, {"there", 2500}, {"\r\nEnd", 4000}},
+			want: []Line{
+				{Time: 1, Text: "Alone"},
+				{Time: 2, Text: "Hello there", Words: []Word{{Start: 2, End: 2.5, Text: "Hello "}, {Start: 2.5, Text: "there"}}},
+				{Time: 4, Text: "End"},
+			},
+		},
+		{
+			name:    "truncated timestamp",
+			entries: []syltEntry{{"One", 1000}},
+			extra:   "Two\x00\x00\x01",
+			want:    []Line{{Time: 1, Text: "One"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSYLT(append(syltBody(3, nil, tt.entries...), tt.extra...))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if !closeTo(got[i].Time, tt.want[i].Time) || got[i].Text != tt.want[i].Text {
+					t.Errorf("line %d = %v %q, want %v %q", i, got[i].Time, got[i].Text, tt.want[i].Time, tt.want[i].Text)
+				}
+				if len(got[i].Words) != len(tt.want[i].Words) {
+					t.Fatalf("line %d words = %+v, want %+v", i, got[i].Words, tt.want[i].Words)
+				}
+				for j, w := range got[i].Words {
+					want := tt.want[i].Words[j]
+					if !closeTo(w.Start, want.Start) || !closeTo(w.End, want.End) || w.Text != want.Text {
+						t.Errorf("line %d word %d = %+v, want %+v", i, j, w, want)
+					}
+				}
+			}
+		})
+	}
+}
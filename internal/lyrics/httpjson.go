@@ -0,0 +1,197 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTTPFields names where each value sits in a result object of a generic
+// JSON service, as dot-separated paths such as "track.name". Empty fields
+// use LRCLIB's names.
+type HTTPFields struct {
+	ID           string
+	Artist       string
+	Title        string
+	Album        string
+	Duration     string
+	Synced       string
+	Plain        string
+	Instrumental string
+}
+
+// defaultHTTPFields are LRCLIB's field names, which other services often
+// copy.
+var defaultHTTPFields = HTTPFields{
+	ID:           "id",
+	Artist:       "artistName",
+	Title:        "trackName",
+	Album:        "albumName",
+	Duration:     "duration",
+	Synced:       "syncedLyrics",
+	Plain:        "plainLyrics",
+	Instrumental: "instrumental",
+}
+
+// HTTPConfig describes a lyrics service that answers GET requests with
+// JSON.
+type HTTPConfig struct {
+	// Name identifies the service in candidates.
+	Name string
+
+	// SearchURL is the search request. {artist}, {title}, {album} and
+	// {duration} (whole seconds) are replaced by the escaped values.
+	SearchURL string
+
+	// GetURL fetches one result by the {id} it gave. Optional.
+	GetURL string
+
+	// Results is the path to the array of results in the response. Empty
+	// means the response is the array, or a single result object.
+	Results string
+
+	Fields  HTTPFields
+	Headers map[string]string
+}
+
+// HTTP is a generic JSON lyrics provider.
+type HTTP struct {
+	config HTTPConfig
+}
+
+func NewHTTP(config HTTPConfig) (*HTTP, error) {
+	if config.SearchURL == "" {
+		return nil, errors.New("lyrics service needs a search URL")
+	}
+	if config.Name == "" {
+		if u, err := url.Parse(config.SearchURL); err == nil && u.Host != "" {
+			config.Name = u.Host
+		} else {
+			config.Name = "http"
+		}
+	}
+	f, d := &config.Fields, defaultHTTPFields
+	setDefault(&f.ID, d.ID)
+	setDefault(&f.Artist, d.Artist)
+	setDefault(&f.Title, d.Title)
+	setDefault(&f.Album, d.Album)
+	setDefault(&f.Duration, d.Duration)
+	setDefault(&f.Synced, d.Synced)
+	setDefault(&f.Plain, d.Plain)
+	setDefault(&f.Instrumental, d.Instrumental)
+	return &HTTP{config: config}, nil
+}
+
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func (p *HTTP) Name() string { return p.config.Name }
+
+func (p *HTTP) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	target := strings.NewReplacer(
+		"{artist}", url.QueryEscape(q.Artist),
+		"{title}", url.QueryEscape(q.Title),
+		"{album}", url.QueryEscape(q.Album),
+		"{duration}", strconv.Itoa(int(q.Duration+0.5)),
+	).Replace(p.config.SearchURL)
+
+	var body any
+	if err := getJSON(ctx, target, p.config.Headers, &body); err != nil {
+		return nil, err
+	}
+	if p.config.Results != "" {
+		body = lookupPath(body, p.config.Results)
+	}
+
+	var results []any
+	switch v := body.(type) {
+	case []any:
+		results = v
+	case map[string]any:
+		results = []any{v}
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s: unexpected response", p.Name())
+	}
+
+	var candidates []Candidate
+	for _, r := range results {
+		if c, ok := p.candidate(r); ok {
+			candidates = append(candidates, c)
+		}
+	}
+	scoreCandidates(q, candidates)
+	return candidates, nil
+}
+
+func (p *HTTP) Get(ctx context.Context, id string) (Candidate, error) {
+	if p.config.GetURL == "" {
+		return Candidate{}, fmt.Errorf("%s: no get URL configured", p.Name())
+	}
+	target := strings.ReplaceAll(p.config.GetURL, "{id}", url.PathEscape(id))
+
+	var body any
+	if err := getJSON(ctx, target, p.config.Headers, &body); err != nil {
+		return Candidate{}, err
+	}
+	c, ok := p.candidate(body)
+	if !ok {
+		return Candidate{}, fmt.Errorf("%s: unexpected response", p.Name())
+	}
+	return c, nil
+}
+
+// candidate reads one result object using the configured field paths.
+func (p *HTTP) candidate(result any) (Candidate, bool) {
+	if _, ok := result.(map[string]any); !ok {
+		return Candidate{}, false
+	}
+	f := p.config.Fields
+	text := func(path string) string { return jsonString(lookupPath(result, path)) }
+
+	fetched := Fetched{Synced: text(f.Synced), Plain: text(f.Plain)}
+	fetched.Instrumental, _ = strconv.ParseBool(text(f.Instrumental))
+	duration, _ := strconv.ParseFloat(text(f.Duration), 64)
+	return Candidate{
+		Provider: p.Name(),
+		ID:       text(f.ID),
+		Artist:   text(f.Artist),
+		Title:    text(f.Title),
+		Album:    text(f.Album),
+		Duration: duration,
+		Lyrics:   fetched.Lyrics(),
+		Fetched:  fetched,
+	}, true
+}
+
+// lookupPath follows a dot-separated path of object keys.
+func lookupPath(v any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// jsonString formats a decoded JSON scalar; objects and arrays give "".
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
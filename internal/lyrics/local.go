@@ -0,0 +1,70 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// errNotFound is returned by Get for IDs that no longer lead to lyrics.
+var errNotFound = errors.New("lyrics not found")
+
+// Local finds lyrics saved in the lyrics folder of the music directory,
+// where fetched lyrics are stored. IDs are file paths.
+type Local struct {
+	MusicDir string
+}
+
+func NewLocal(musicDir string) *Local {
+	return &Local{MusicDir: musicDir}
+}
+
+func (p *Local) Name() string { return "local" }
+
+func (p *Local) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	path, ly, found := findByName(q.Name, p.MusicDir)
+	if !found {
+		return nil, nil
+	}
+	return []Candidate{{Provider: p.Name(), ID: path, Confidence: 1, Lyrics: ly}}, nil
+}
+
+func (p *Local) Get(ctx context.Context, id string) (Candidate, error) {
+	return loadCandidate(p.Name(), id)
+}
+
+// Sidecar finds a lyrics file next to the audio file with the same base
+// name, e.g. "01 Song.lrc" beside "01 Song.flac". IDs are file paths.
+type Sidecar struct{}
+
+func NewSidecar() *Sidecar {
+	return &Sidecar{}
+}
+
+func (p *Sidecar) Name() string { return "sidecar" }
+
+func (p *Sidecar) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	if q.FilePath == "" {
+		return nil, nil
+	}
+	base := strings.TrimSuffix(q.FilePath, filepath.Ext(q.FilePath))
+	for _, ext := range []string{".lrc", ".LRC", ".txt", ".TXT"} {
+		if ly, found := LoadPath(base + ext); found {
+			return []Candidate{{Provider: p.Name(), ID: base + ext, Confidence: 1, Lyrics: ly}}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *Sidecar) Get(ctx context.Context, id string) (Candidate, error) {
+	return loadCandidate(p.Name(), id)
+}
+
+func loadCandidate(provider, path string) (Candidate, error) {
+	ly, found := LoadPath(path)
+	if !found {
+		return Candidate{}, errNotFound
+	}
+	return Candidate{Provider: provider, ID: path, Confidence: 1, Lyrics: ly}, nil
+}
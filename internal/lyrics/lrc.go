@@ -0,0 +1,362 @@
+package lyrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed LRC file: its ID tags and its timed lines.
+type Document struct {
+	// Tags holds every ID tag by lower-case name, e.g. "ar", "ti" or a
+	// tool's own tags. The fields below are the standard ones, decoded.
+	Tags map[string]string
+
+	Title  string // [ti:]
+	Artist string // [ar:]
+	Album  string // [al:]
+	Author string // [au:], the songwriter
+	By     string // [by:], who made the LRC file
+
+	// Length is the song length from [length:], in seconds, or zero.
+	Length float64
+
+	// Instrumental is set by an [instrumental:true] tag, which marks a
+	// song known to have no lyrics.
+	Instrumental bool
+
+	// Offset is the [offset:] tag in seconds. A positive offset shows the
+	// lines earlier. It has already been applied to Lines.
+	Offset float64
+
+	// Lines are in time order. A line with several timestamps appears once
+	// per timestamp. Timed lines without text are kept; they end the
+	// previous line, e.g. for an instrumental break.
+	Lines []Line
+
+	// Plain holds the untimed text of a file without timed lines: unsynced
+	// lyrics kept with their tags. Once text has started, lines that look
+	// like tags, such as "[Chorus: Name]", are part of it.
+	Plain string
+
+	// timed holds Lines as the file times them, before Offset.
+	timed []Line
+
+	// hoursFirst reads stamps of three colon-separated fields as hh:mm:ss,
+	// see hoursFirst.
+	hoursFirst bool
+}
+
+// Parse returns the timed lines of an LRC file with its offset applied.
+func Parse(content string) []Line {
+	return ParseDocument(content).Lines
+}
+
+// ParseDocument parses an LRC file. Timestamps may be [mm:ss], [mm:ss.x]
+// to [mm:ss.xxx], [hh:mm:ss.xx], and [mm:ss:xx] or [hh:mm:ss] as the file
+// turns out to use, and any number of them may start a line. Lines that are
+// neither timed nor a tag only count as Plain when nothing is timed.
+func ParseDocument(content string) Document {
+	doc := Document{Tags: make(map[string]string)}
+	content = strings.TrimPrefix(content, "\ufeff")
+	lines := strings.Split(content, "\n")
+	doc.hoursFirst = hoursFirst(lines)
+
+	var untimed []string
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+
+		var times []float64
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			t, ok := parseTimestamp(line[1:end], doc.hoursFirst)
+			if !ok {
+				break
+			}
+			times = append(times, t)
+			line = line[end+1:]
+		}
+
+		if len(times) == 0 {
+			if name, value, ok := parseTag(line); ok {
+				doc.setTag(name, value)
+				if len(untimed) == 0 {
+					continue
+				}
+			}
+			if line != "" || len(untimed) > 0 {
+				untimed = append(untimed, line)
+			}
+			continue
+		}
+
+		text, words := parseWords(line, times[0], doc.hoursFirst)
+		for _, t := range times {
+			doc.Lines = append(doc.Lines, Line{Time: t, Text: text, Words: shiftWords(words, t-times[0])})
+		}
+	}
+
+	if len(doc.Lines) == 0 {
+		doc.Plain = strings.TrimSpace(strings.Join(untimed, "\n"))
+	}
+
+	// Stable, so lines sharing a timestamp keep the file's order. Moving
+	// every line by the offset keeps that order.
+	sort.SliceStable(doc.Lines, func(i, j int) bool {
+		return doc.Lines[i].Time < doc.Lines[j].Time
+	})
+
+	doc.timed = doc.Lines
+	if doc.Offset != 0 {
+		doc.Lines = make([]Line, len(doc.timed))
+		for i, line := range doc.timed {
+			doc.Lines[i] = Line{Time: max(line.Time-doc.Offset, 0), Text: line.Text, Words: shiftWords(line.Words, -doc.Offset)}
+		}
+	}
+
+	return doc
+}
+
+// parseWords splits the text of an enhanced LRC line at its <mm:ss.xx>
+// word marks. Text before the first mark starts with the line. A mark at
+// the very end closes the last word. Lines without marks yield no words.
+func parseWords(text string, lineTime float64, hoursFirst bool) (string, []Word) {
+	var words []Word
+	var plain strings.Builder
+	start := lineTime
+	for {
+		open := strings.IndexByte(text, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(text[open:], '>')
+		if end < 0 {
+			break
+		}
+		end += open
+		t, ok := parseTimestamp(text[open+1:end], hoursFirst)
+		if !ok {
+			// Not a word mark; keep it as text.
+			plain.WriteString(text[:end+1])
+			if len(words) > 0 {
+				words[len(words)-1].Text += text[:end+1]
+			}
+			text = text[end+1:]
+			continue
+		}
+
+		if segment := text[:open]; segment != "" {
+			if len(words) == 0 {
+				words = append(words, Word{Start: start})
+			}
+			words[len(words)-1].Text += segment
+			plain.WriteString(segment)
+		}
+		if len(words) > 0 {
+			words[len(words)-1].End = t
+		}
+		words = append(words, Word{Start: t})
+		text = text[end+1:]
+	}
+	plain.WriteString(text)
+	if len(words) == 0 {
+		return strings.TrimSpace(plain.String()), nil
+	}
+
+	words[len(words)-1].Text += text
+	if words[len(words)-1].Text == "" {
+		// A closing mark: it only ended the previous word.
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return strings.TrimSpace(plain.String()), nil
+	}
+	// A2 files pad the marks with spaces: "<00:01.00> One <00:01.60> two".
+	return strings.Join(strings.Fields(plain.String()), " "), words
+}
+
+// shiftWords copies words moved by delta seconds, for lines repeated at
+// several timestamps and for the offset. It stops at zero.
+func shiftWords(words []Word, delta float64) []Word {
+	if words == nil || delta == 0 {
+		return words
+	}
+	shifted := make([]Word, len(words))
+	for i, w := range words {
+		shifted[i] = Word{Start: max(w.Start+delta, 0), Text: w.Text}
+		if w.End > 0 {
+			shifted[i].End = max(w.End+delta, 0)
+		}
+	}
+	return shifted
+}
+
+// parseTag reads an ID tag line such as [ar:Artist]. Names are letters,
+// digits, '#' and '-'; anything after the closing bracket disqualifies the
+// line.
+func parseTag(line string) (name, value string, ok bool) {
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", "", false
+	}
+	inner := line[1 : len(line)-1]
+	colon := strings.IndexByte(inner, ':')
+	if colon <= 0 {
+		return "", "", false
+	}
+	name = inner[:colon]
+	for _, r := range name {
+		isAlnum := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+		if !isAlnum && r != '#' && r != '-' && r != '_' {
+			return "", "", false
+		}
+	}
+	return strings.ToLower(name), strings.TrimSpace(inner[colon+1:]), true
+}
+
+func (d *Document) setTag(name, value string) {
+	d.Tags[name] = value
+	switch name {
+	case "ti":
+		d.Title = value
+	case "ar":
+		d.Artist = value
+	case "al":
+		d.Album = value
+	case "au":
+		d.Author = value
+	case "by":
+		d.By = value
+	case "length":
+		if t, ok := parseTimestamp(value, d.hoursFirst); ok {
+			d.Length = t
+		}
+	case "instrumental":
+		d.Instrumental = value != "" && !strings.EqualFold(value, "false")
+	case "offset":
+		if ms, err := strconv.ParseFloat(strings.TrimPrefix(value, "+"), 64); err == nil {
+			d.Offset = ms / 1000
+		}
+	}
+}
+
+// hoursFirst reports whether the file's stamps of three colon-separated
+// fields, such as [01:02:03], are hh:mm:ss rather than the more common
+// mm:ss:xx. Read as mm:ss:xx, hundredths of 60 and over are bound to turn
+// up and most lines of a song come after its first minute; a file with
+// neither is taken for hh:mm:ss.
+func hoursFirst(lines []string) bool {
+	count, firstZero := 0, 0
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			parts := strings.Split(line[1:end], ":")
+			line = line[end+1:]
+			if len(parts) != 3 || strings.Contains(parts[2], ".") {
+				continue
+			}
+			first, err1 := strconv.Atoi(parts[0])
+			third, err2 := strconv.Atoi(parts[2])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			if third >= 60 {
+				return false
+			}
+			count++
+			if first == 0 {
+				firstZero++
+			}
+		}
+	}
+	return count > 0 && firstZero*2 >= count
+}
+
+// parseTimestamp converts the inside of a time tag to seconds. The
+// fraction is scaled by its number of digits, so .5, .50 and .500 are all
+// half a second. hoursFirst reads three fields without a fraction as
+// hh:mm:ss instead of mm:ss:xx.
+func parseTimestamp(s string, hoursFirst bool) (float64, bool) {
+	s = strings.TrimSpace(s)
+	var hours, minutes, seconds int
+	var fraction string
+
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 2:
+		// mm:ss or mm:ss.xx
+		sec, frac, _ := strings.Cut(parts[1], ".")
+		parts = []string{"0", parts[0], sec}
+		fraction = frac
+	case 3:
+		sec, frac, found := strings.Cut(parts[2], ".")
+		switch {
+		case found:
+			// hh:mm:ss.xx
+			parts[2] = sec
+			fraction = frac
+		case !hoursFirst:
+			// mm:ss:xx, a common variant of mm:ss.xx
+			fraction = parts[2]
+			parts = []string{"0", parts[0], parts[1]}
+		}
+		// Otherwise hh:mm:ss, as it stands.
+	default:
+		return 0, false
+	}
+
+	values := []*int{&hours, &minutes, &seconds}
+	for i, part := range parts {
+		if !isDigits(part) {
+			return 0, false
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		*values[i] = v
+	}
+	if len(parts[2]) > 2 || seconds >= 60 || (hours > 0 && minutes >= 60) {
+		return 0, false
+	}
+
+	t := float64(hours*3600 + minutes*60 + seconds)
+	if fraction != "" {
+		if !isDigits(fraction) || len(fraction) > 9 {
+			return 0, false
+		}
+		f, _ := strconv.Atoi(fraction)
+		scale := 1.0
+		for range fraction {
+			scale *= 10
+		}
+		t += float64(f) / scale
+	}
+	return t, true
+}
+
+// FormatTimestamp writes seconds as an LRC time tag's [mm:ss.xx] inside.
+func FormatTimestamp(t float64) string {
+	cs := int(math.Round(max(t, 0) * 100))
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+package lyrics
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Line
+	}{
+		{
+			name:    "fraction",
+			content: "[01:02.500]line",
+			want:    []Line{{Time: 62.5, Text: "line"}},
+		},
+		{
+			name:    "several timestamps",
+			content: "[00:12.00][01:30.00]chorus",
+			want:    []Line{{Time: 12, Text: "chorus"}, {Time: 90, Text: "chorus"}},
+		},
+		{
+			name:    "positive offset",
+			content: "[offset:+500]\n[00:10.00]a\n[00:00.20]b",
+			want:    []Line{{Time: 0, Text: "b"}, {Time: 9.5, Text: "a"}},
+		},
+		{
+			name:    "negative offset",
+			content: "[offset:-250]\n[00:10.00]a",
+			want:    []Line{{Time: 10.25, Text: "a"}},
+		},
+		{
+			name:    "crlf and bom",
+			content: "\ufeff[00:01.00]one\r\n[00:02.00]two\r\n",
+			want:    []Line{{Time: 1, Text: "one"}, {Time: 2, Text: "two"}},
+		},
+		{
+			name:    "timestamp variants",
+			content: "[01:00:02.5]hour\n[2:03]short\n[00:01.123]millis",
+			want:    []Line{{Time: 1.123, Text: "millis"}, {Time: 123, Text: "short"}, {Time: 3602.5, Text: "hour"}},
+		},
+		{
+			name:    "mm:ss:xx",
+			content: "[00:04:50]a\n[01:12:75]b\n[02:30:10]c",
+			want:    []Line{{Time: 4.5, Text: "a"}, {Time: 72.75, Text: "b"}, {Time: 150.1, Text: "c"}},
+		},
+		{
+			name:    "hh:mm:ss",
+			content: "[00:04:50]a\n[00:30:00]b\n[01:02:15]c",
+			want:    []Line{{Time: 290, Text: "a"}, {Time: 1800, Text: "b"}, {Time: 3735, Text: "c"}},
+		},
+		{
+			name:    "untimed lines ignored",
+			content: "stray\n[xx:yy]bad\n[00:01.00]ok",
+			want:    []Line{{Time: 1, Text: "ok"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDocument(tt.content).Lines
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if !closeTo(got[i].Time, tt.want[i].Time) || got[i].Text != tt.want[i].Text {
+					t.Errorf("line %d = %v %q, want %v %q", i, got[i].Time, got[i].Text, tt.want[i].Time, tt.want[i].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDocumentTags(t *testing.T) {
+	doc := ParseDocument("[ar:Artist]\n[ti: Title ]\n[al:Album]\n[length:03:25.50]\n[offset:+500]\n[x-tool:1]\n")
+	if doc.Artist != "Artist" || doc.Title != "Title" || doc.Album != "Album" {
+		t.Errorf("got artist %q, title %q, album %q", doc.Artist, doc.Title, doc.Album)
+	}
+	if !closeTo(doc.Length, 205.5) {
+		t.Errorf("length = %v, want 205.5", doc.Length)
+	}
+	if !closeTo(doc.Offset, 0.5) {
+		t.Errorf("offset = %v, want 0.5", doc.Offset)
+	}
+	if doc.Tags["x-tool"] != "1" {
+		t.Errorf("tags = %v, want x-tool kept", doc.Tags)
+	}
+}
+
+func TestParseDocumentWords(t *testing.T) {
+	doc := ParseDocument("[00:01.00]<00:01.00>Hello <00:01.60>world<00:02.40>")
+	if len(doc.Lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(doc.Lines))
+	}
+	line := doc.Lines[0]
+	if line.Text != "Hello world" {
+		t.Errorf("text = %q, want %q", line.Text, "Hello world")
+	}
+	want := []Word{{Start: 1, End: 1.6, Text: "Hello "}, {Start: 1.6, End: 2.4, Text: "world"}}
+	if len(line.Words) != len(want) {
+		t.Fatalf("got words %+v, want %+v", line.Words, want)
+	}
+	for i, w := range line.Words {
+		if !closeTo(w.Start, want[i].Start) || !closeTo(w.End, want[i].End) || w.Text != want[i].Text {
+			t.Errorf("word %d = %+v, want %+v", i, w, want[i])
+		}
+	}
+}
+
+func TestParseDocumentTestdata(t *testing.T) {
+	tests := []struct {
+		file        string
+		title       string
+		lines       int
+		first, last Line
+		words       int // of the first line
+		length      float64
+	}{
+		{
+			file:   "windows_bom.lrc",
+			title:  "Paper Lanterns",
+			lines:  6,
+			first:  Line{Time: 12.23, Text: "Paper lanterns on the water"},
+			last:   Line{Time: 169.75},
+			length: 178.4,
+		},
+		{
+			file:  "lrclib.lrc",
+			lines: 6,
+			first: Line{Time: 17.12, Text: "Morning trains are running late"},
+			last:  Line{Time: 200},
+		},
+		{
+			file:  "enhanced.lrc",
+			title: "Counting Stars Slowly",
+			lines: 3,
+			first: Line{Time: 1.12, Text: "One two three"},
+			last:  Line{Time: 7.12, Text: "no marks on this line"},
+			words: 3,
+		},
+		{
+			file:  "millis.lrc",
+			title: "Precise",
+			lines: 4,
+			first: Line{Time: 1.123, Text: "First beat"},
+			last:  Line{Time: 5.5, Text: "Short fraction"},
+		},
+		{
+			file:   "hours.lrc",
+			title:  "Long Mix",
+			lines:  4,
+			first:  Line{Time: 0, Text: "Intro"},
+			last:   Line{Time: 3735, Text: "Closing track"},
+			length: 4200,
+		},
+		{
+			file:  "multistamp.lrc",
+			title: "Round and Round",
+			lines: 7,
+			first: Line{Time: 5, Text: "First verse"},
+			last:  Line{Time: 75, Text: "Round and round we go"},
+		},
+		{
+			file:  "instrumental.lrc",
+			title: "Breaks",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc := ParseDocument(string(data))
+			if doc.Title != tt.title {
+				t.Errorf("title = %q, want %q", doc.Title, tt.title)
+			}
+			if !closeTo(doc.Length, tt.length) {
+				t.Errorf("length = %v, want %v", doc.Length, tt.length)
+			}
+			if len(doc.Lines) != tt.lines {
+				t.Fatalf("got %d lines, want %d", len(doc.Lines), tt.lines)
+			}
+			if tt.lines == 0 {
+				return
+			}
+			first, last := doc.Lines[0], doc.Lines[len(doc.Lines)-1]
+			if !closeTo(first.Time, tt.first.Time) || first.Text != tt.first.Text {
+				t.Errorf("first line = %v %q, want %v %q", first.Time, first.Text, tt.first.Time, tt.first.Text)
+			}
+			if !closeTo(last.Time, tt.last.Time) || last.Text != tt.last.Text {
+				t.Errorf("last line = %v %q, want %v %q", last.Time, last.Text, tt.last.Time, tt.last.Text)
+			}
+			if len(first.Words) != tt.words {
+				t.Errorf("first line has %d words, want %d", len(first.Words), tt.words)
+			}
+		})
+	}
+}
+
+func FuzzParseDocument(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.lrc"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		doc := ParseDocument(content)
+		for i, line := range doc.Lines {
+			if line.Time < 0 || math.IsNaN(line.Time) || math.IsInf(line.Time, 0) {
+				t.Fatalf("line %d has time %v", i, line.Time)
+			}
+			if i > 0 && line.Time < doc.Lines[i-1].Time {
+				t.Fatalf("line %d at %v comes before line %d at %v", i, line.Time, i-1, doc.Lines[i-1].Time)
+			}
+			for _, w := range line.Words {
+				if w.Start < 0 || w.End < 0 {
+					t.Fatalf("line %d has word %+v", i, w)
+				}
+			}
+		}
+	})
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,136 @@
+package lyrics
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultLRCLIBURL is the public LRCLIB instance.
+const DefaultLRCLIBURL = "https://lrclib.net"
+
+type lrclibResult struct {
+	ID           int     `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	Instrumental bool    `json:"instrumental"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+func (r lrclibResult) candidate() Candidate {
+	fetched := Fetched{Synced: r.SyncedLyrics, Plain: r.PlainLyrics, Instrumental: r.Instrumental}
+	return Candidate{
+		Provider: "lrclib",
+		ID:       strconv.Itoa(r.ID),
+		Artist:   r.ArtistName,
+		Title:    r.TrackName,
+		Album:    r.AlbumName,
+		Duration: r.Duration,
+		Lyrics:   fetched.Lyrics(),
+		Fetched:  fetched,
+	}
+}
+
+// LRCLIB looks lyrics up on an LRCLIB server, the public one or a
+// self-hosted mirror. IDs are LRCLIB record IDs.
+type LRCLIB struct {
+	BaseURL string
+}
+
+// NewLRCLIB returns a provider for the server at baseURL, or the public
+// instance when baseURL is empty.
+func NewLRCLIB(baseURL string) *LRCLIB {
+	if baseURL == "" {
+		baseURL = DefaultLRCLIBURL
+	}
+	return &LRCLIB{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (p *LRCLIB) Name() string { return "lrclib" }
+
+// Search first asks for an exact match: LRCLIB's /api/get matches the
+// artist, title and album and a length within two seconds. Otherwise it
+// searches by artist and title, then by title alone, and scores what comes
+// back.
+func (p *LRCLIB) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	album := q.Album
+	if album == "Unknown Album" {
+		album = ""
+	}
+
+	if q.Artist != "" && q.Title != "" && album != "" && q.Duration > 0 {
+		params := url.Values{}
+		params.Add("artist_name", q.Artist)
+		params.Add("track_name", q.Title)
+		params.Add("album_name", album)
+		params.Add("duration", strconv.Itoa(int(math.Round(q.Duration))))
+		var result lrclibResult
+		if err := getJSON(ctx, p.BaseURL+"/api/get?"+params.Encode(), nil, &result); err == nil {
+			exact := result.candidate()
+			exact.Confidence = 1
+			if exact.Lyrics.Found() {
+				return []Candidate{exact}, nil
+			}
+		}
+	}
+
+	params := url.Values{}
+	params.Add("artist_name", q.Artist)
+	params.Add("track_name", q.Title)
+	if album != "" {
+		params.Add("album_name", album)
+	}
+	candidates, err := p.search(ctx, params)
+	if err == nil {
+		scoreCandidates(q, candidates)
+		if best, ok := bestCandidate(candidates); ok && best.Confidence >= MinConfidence {
+			return candidates, nil
+		}
+	}
+
+	params = url.Values{}
+	params.Add("q", q.Title)
+	more, err := p.search(ctx, params)
+	if err != nil {
+		if len(candidates) > 0 {
+			return candidates, nil
+		}
+		return nil, err
+	}
+	seen := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		seen[c.ID] = true
+	}
+	for _, c := range more {
+		if !seen[c.ID] {
+			candidates = append(candidates, c)
+		}
+	}
+	scoreCandidates(q, candidates)
+	return candidates, nil
+}
+
+func (p *LRCLIB) search(ctx context.Context, params url.Values) ([]Candidate, error) {
+	var results []lrclibResult
+	if err := getJSON(ctx, p.BaseURL+"/api/search?"+params.Encode(), nil, &results); err != nil {
+		return nil, err
+	}
+	candidates := make([]Candidate, len(results))
+	for i, r := range results {
+		candidates[i] = r.candidate()
+	}
+	return candidates, nil
+}
+
+func (p *LRCLIB) Get(ctx context.Context, id string) (Candidate, error) {
+	var result lrclibResult
+	if err := getJSON(ctx, p.BaseURL+"/api/get/"+url.PathEscape(id), nil, &result); err != nil {
+		return Candidate{}, err
+	}
+	return result.candidate(), nil
+}
@@ -1,108 +1,199 @@
 package lyrics
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 )
 
 type Line struct {
 	Time float64
 	Text string
+
+	// Words holds per-word timing from enhanced LRC (<mm:ss.xx>word). It is
+	// nil for lines timed only as a whole.
+	Words []Word
+}
+
+// Word is a timed segment of an enhanced LRC line. Text keeps its
+// surrounding spaces so the words of a line join back into its text. End is
+// zero when the file doesn't say; the word then lasts until the next one.
+type Word struct {
+	Start float64
+	End   float64
+	Text  string
 }
 
 type Lyrics struct {
-	Lines  []Line
+	// Lines are timed as the file has them, before Offset; At and Position
+	// convert between their times and the song's.
+	Lines []Line
+
+	// Plain holds unsynced lyrics, shown as they are, for songs without
+	// timed lines.
+	Plain string
+
+	// Instrumental marks a song known to have no lyrics.
+	Instrumental bool
+
+	// Match says where fetched lyrics came from, when that was recorded.
+	Match Match
+
+	// Path is the file the lyrics were read from or saved to, empty for
+	// lyrics from the audio file's tags.
+	Path string
+
+	// Offset is the file's [offset:] in seconds. A positive offset shows
+	// the lines earlier.
+	Offset float64
+
 	Loaded bool
 }
 
-type lrclibResult struct {
-	TrackName    string `json:"trackName"`
-	ArtistName   string `json:"artistName"`
-	AlbumName    string `json:"albumName"`
-	SyncedLyrics string `json:"syncedLyrics"`
+// fromDocument returns the synced lyrics of a parsed LRC file.
+func fromDocument(doc Document) Lyrics {
+	return Lyrics{Lines: doc.timed, Offset: doc.Offset, Loaded: true}
 }
 
-var (
-	timestampRegexp = regexp.MustCompile(`\[(\d+):(\d+)\.(\d+)\]\s*(.*)`)
-	cleanNameRegexp = regexp.MustCompile(`^\d+\s*-?\s*`)
-)
+// At returns the time in the lines' timing that is sung at position
+// seconds into the song.
+func (l Lyrics) At(position float64) float64 {
+	return position + l.Offset
+}
 
-func Parse(content string) []Line {
-	var lines []Line
+// Position returns how far into the song a time in the lines' timing is
+// sung. Times the offset moves before the start are shown at zero.
+func (l Lyrics) Position(t float64) float64 {
+	return max(t-l.Offset, 0)
+}
 
-	for _, line := range strings.Split(content, "\n") {
-		matches := timestampRegexp.FindStringSubmatch(line)
-		if len(matches) != 5 {
-			continue
-		}
+// Found reports whether there is anything to show, lyrics or an
+// instrumental marker.
+func (l Lyrics) Found() bool {
+	return len(l.Lines) > 0 || l.Plain != "" || l.Instrumental
+}
 
-		min, _ := strconv.Atoi(matches[1])
-		sec, _ := strconv.Atoi(matches[2])
-		ms, _ := strconv.Atoi(matches[3])
+// Fetched is what an online lookup found for a song. At most one of Synced
+// and Plain is used: synced lyrics win.
+type Fetched struct {
+	Synced       string
+	Plain        string
+	Instrumental bool
+}
 
-		timeInSeconds := float64(min*60) + float64(sec) + float64(ms)/100.0
-		text := strings.TrimSpace(matches[4])
+// Lyrics returns the lyrics as they will read back once saved.
+func (f Fetched) Lyrics() Lyrics {
+	switch {
+	case f.Synced != "":
+		return fromDocument(ParseDocument(f.Synced))
+	case f.Plain != "":
+		return ParsePlain(f.Plain)
+	case f.Instrumental:
+		return Lyrics{Instrumental: true, Loaded: true}
+	}
+	return Lyrics{Loaded: true}
+}
 
-		if text == "" {
-			continue
-		}
+// Empty reports whether nothing was fetched.
+func (f Fetched) Empty() bool {
+	return f.Synced == "" && f.Plain == "" && !f.Instrumental
+}
 
-		lines = append(lines, Line{
-			Time: timeInSeconds,
-			Text: text,
-		})
-	}
+var cleanNameRegexp = regexp.MustCompile(`^\d+\s*-?\s*`)
 
-	sort.Slice(lines, func(i, j int) bool {
-		return lines[i].Time < lines[j].Time
-	})
+func LoadFromFile(songPath, musicDir string) (Lyrics, bool) {
+	return LoadByName(strings.TrimSuffix(filepath.Base(songPath), filepath.Ext(songPath)), musicDir)
+}
 
-	return lines
+// LoadByName looks up the LRC file for a song named baseName, for songs
+// whose file name says nothing about them such as tracks of a CUE sheet.
+func LoadByName(baseName, musicDir string) (Lyrics, bool) {
+	_, ly, found := findByName(baseName, musicDir)
+	return ly, found
 }
 
-func LoadFromFile(songPath, musicDir string) (Lyrics, bool) {
-	baseName := strings.TrimSuffix(filepath.Base(songPath), filepath.Ext(songPath))
+// findByName is LoadByName that also returns the file it read.
+func findByName(baseName, musicDir string) (string, Lyrics, bool) {
 	cleanName := cleanNameRegexp.ReplaceAllString(baseName, "")
 
 	lyricsDir := filepath.Join(musicDir, "lyrics")
 	if err := os.MkdirAll(lyricsDir, 0755); err != nil {
-		return Lyrics{}, false
+		return "", Lyrics{}, false
 	}
 
-	patterns := []string{
-		filepath.Join(lyricsDir, cleanName+".lrc"),
-		filepath.Join(lyricsDir, baseName+".lrc"),
-		filepath.Join(lyricsDir, strings.ToLower(cleanName)+".lrc"),
-		filepath.Join(lyricsDir, strings.ToLower(baseName)+".lrc"),
+	names := []string{
+		cleanName,
+		baseName,
+		strings.ToLower(cleanName),
+		strings.ToLower(baseName),
 	}
 
-	for _, candidate := range patterns {
-		content, err := os.ReadFile(candidate)
-		if err != nil {
-			continue
+	// Synced lyrics under any of the names win over plain text.
+	for _, ext := range []string{".lrc", ".txt"} {
+		for _, name := range names {
+			path := filepath.Join(lyricsDir, name+ext)
+			if ly, found := LoadPath(path); found {
+				return path, ly, true
+			}
 		}
+	}
 
-		lines := Parse(string(content))
-		if len(lines) == 0 {
-			continue
-		}
+	return "", Lyrics{}, false
+}
 
-		return Lyrics{Lines: lines, Loaded: true}, true
+// LoadPath reads a lyrics file: synced or instrumental if it ends in .lrc,
+// plain text otherwise.
+func LoadPath(path string) (Lyrics, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Lyrics{}, false
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".lrc") {
+		doc := ParseDocument(string(content))
+		match := matchFromTags(doc.Tags)
+		if doc.Instrumental {
+			return Lyrics{Instrumental: true, Match: match, Path: path, Loaded: true}, true
+		}
+		if len(doc.Lines) == 0 {
+			// Plain lyrics saved with the tags of their match.
+			plain := ParsePlain(doc.Plain)
+			plain.Match = match
+			plain.Path = path
+			return plain, plain.Found()
+		}
+		ly := fromDocument(doc)
+		ly.Match = match
+		ly.Path = path
+		return ly, true
 	}
 
-	return Lyrics{}, false
+	plain := ParsePlain(string(content))
+	plain.Path = path
+	return plain, plain.Found()
+}
+
+// ParsePlain reads unsynced lyrics. A text that only says "Instrumental"
+// marks the song as instrumental.
+func ParsePlain(content string) Lyrics {
+	content = strings.TrimPrefix(content, "\ufeff")
+	text := strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	switch strings.ToLower(strings.Trim(text, "[]() ")) {
+	case "":
+		return Lyrics{Loaded: true}
+	case "instrumental":
+		return Lyrics{Instrumental: true, Loaded: true}
+	}
+	return Lyrics{Plain: text, Loaded: true}
 }
 
 func SaveToFile(songPath, musicDir, content string) (string, error) {
-	baseName := strings.TrimSuffix(filepath.Base(songPath), filepath.Ext(songPath))
+	return SaveByName(strings.TrimSuffix(filepath.Base(songPath), filepath.Ext(songPath)), musicDir, content)
+}
+
+// SaveByName stores lyrics under the name LoadByName looks up.
+func SaveByName(baseName, musicDir, content string) (string, error) {
 	cleanName := cleanNameRegexp.ReplaceAllString(baseName, "")
 
 	lyricsDir := filepath.Join(musicDir, "lyrics")
@@ -118,47 +209,48 @@ func SaveToFile(songPath, musicDir, content string) (string, error) {
 	return path, nil
 }
 
-func FetchFromAPI(artist, title, album string) (string, error) {
-	baseURL := "https://lrclib.net/api/search"
-	params := url.Values{}
-	params.Add("artist_name", artist)
-	params.Add("track_name", title)
-	if album != "" && album != "Unknown Album" {
-		params.Add("album_name", album)
+// instrumentalLRC is what an instrumental song's LRC file holds.
+const instrumentalLRC = "[instrumental:true]\n"
+
+// SaveFetched stores what a lookup found under the name LoadByName looks
+// up, always as an .lrc whose tags record the match: synced lyrics as they
+// are, plain lyrics as untimed text and an instrumental with only an
+// [instrumental:true] tag, so the song isn't looked up again. Lyrics saved
+// for the song before are replaced. It returns the lyrics as LoadByName
+// would.
+func SaveFetched(baseName, musicDir string, f Fetched, match Match) (Lyrics, error) {
+	if err := removeByName(baseName, musicDir); err != nil {
+		return Lyrics{}, err
 	}
-
-	content, err := performRequest(baseURL, params)
-	if err == nil {
-		return content, nil
+	var path string
+	var err error
+	switch {
+	case f.Synced != "":
+		path, err = SaveByName(baseName, musicDir, matchTags(match)+f.Synced)
+	case f.Plain != "":
+		path, err = SaveByName(baseName, musicDir, matchTags(match)+f.Plain)
+	case f.Instrumental:
+		path, err = SaveByName(baseName, musicDir, instrumentalLRC+matchTags(match))
 	}
-
-	// fallback search using q param
-	params = url.Values{}
-	params.Add("q", title)
-	return performRequest(baseURL, params)
-}
-
-func performRequest(baseURL string, params url.Values) (string, error) {
-	resp, err := http.Get(baseURL + "?" + params.Encode())
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-
-	var results []lrclibResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return "", err
+		return Lyrics{}, err
 	}
+	ly := f.Lyrics()
+	ly.Match = match
+	ly.Path = path
+	return ly, nil
+}
 
-	for _, result := range results {
-		if result.SyncedLyrics != "" {
-			return result.SyncedLyrics, nil
+// removeByName deletes every lyrics file LoadByName would find for a song,
+// so what is saved next isn't shadowed by an older file.
+func removeByName(baseName, musicDir string) error {
+	for {
+		path, _, found := findByName(baseName, musicDir)
+		if !found {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
 		}
 	}
-
-	return "", fmt.Errorf("no synced lyrics found")
 }
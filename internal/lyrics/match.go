@@ -0,0 +1,146 @@
+package lyrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Player/internal/textmatch"
+)
+
+const (
+	// MinConfidence is the lowest score at which search results are used
+	// without asking. Lower ones are likely another song of the same name.
+	MinConfidence = 0.75
+
+	// DurationTolerance is how many seconds a result's length may differ
+	// from the song's. Synced lyrics of another cut would be mis-timed.
+	DurationTolerance = 3.0
+)
+
+// Match records which provider result lyrics came from, so wrong lyrics
+// can be traced back.
+type Match struct {
+	Provider   string
+	ID         string
+	Confidence float64
+}
+
+// Known reports whether the lyrics' origin was recorded.
+func (m Match) Known() bool {
+	return m.Provider != ""
+}
+
+// Match returns where the candidate came from.
+func (c Candidate) Match() Match {
+	return Match{Provider: c.Provider, ID: c.ID, Confidence: c.Confidence}
+}
+
+// scoreCandidates sets each candidate's confidence for the query and sorts
+// the best first.
+func scoreCandidates(q Query, candidates []Candidate) {
+	for i := range candidates {
+		candidates[i].Confidence = score(q, candidates[i])
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+}
+
+// placeholders are what the scan puts in place of missing tags. They say
+// nothing about the song.
+var placeholders = map[string]bool{"Unknown Artist": true, "Unknown Album": true}
+
+// score rates how likely a candidate is the queried song, from 0 to 1.
+// The title weighs most, then the artist, then the album; each only counts
+// when both sides name one. A length outside DurationTolerance rules it out.
+func score(q Query, c Candidate) float64 {
+	if q.Duration > 0 && c.Duration > 0 && math.Abs(q.Duration-c.Duration) > DurationTolerance {
+		return 0
+	}
+
+	total, weight := 0.0, 0.0
+	add := func(a, b string, w float64) {
+		if placeholders[a] || placeholders[b] {
+			return
+		}
+		a, b = textmatch.Normalize(a), textmatch.Normalize(b)
+		if a == "" || b == "" {
+			return
+		}
+		total += similarity(a, b) * w
+		weight += w
+	}
+	add(q.Title, c.Title, 0.5)
+	add(q.Artist, c.Artist, 0.35)
+	add(q.Album, c.Album, 0.15)
+	if weight == 0 {
+		return 0
+	}
+	return total / weight
+}
+
+// similarity compares two normalized strings: 1 when equal, otherwise one
+// minus their edit distance relative to the longer one. Artists credited
+// together ("A and B" for "A") count as close.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+	s := 1 - float64(levenshtein(ra, rb))/float64(longer)
+	if strings.HasPrefix(a, b+" ") || strings.HasPrefix(b, a+" ") {
+		s = max(s, 0.8)
+	}
+	return s
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Tags recording a match in saved LRC files.
+const (
+	sourceTag     = "source"
+	sourceIDTag   = "sourceid"
+	confidenceTag = "confidence"
+)
+
+// matchTags returns the LRC tag lines recording m.
+func matchTags(m Match) string {
+	if !m.Known() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("[" + sourceTag + ":" + m.Provider + "]\n")
+	if m.ID != "" {
+		b.WriteString("[" + sourceIDTag + ":" + m.ID + "]\n")
+	}
+	b.WriteString("[" + confidenceTag + ":" + strconv.FormatFloat(m.Confidence, 'f', 2, 64) + "]\n")
+	return b.String()
+}
+
+// matchFromTags reads what matchTags wrote.
+func matchFromTags(tags map[string]string) Match {
+	m := Match{Provider: tags[sourceTag], ID: tags[sourceIDTag]}
+	m.Confidence, _ = strconv.ParseFloat(tags[confidenceTag], 64)
+	return m
+}
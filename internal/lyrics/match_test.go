@@ -0,0 +1,94 @@
+package lyrics
+
+import "testing"
+
+func TestScorePlaceholders(t *testing.T) {
+	c := Candidate{Artist: "Daft Punk", Title: "One More Time", Album: "Discovery"}
+	for _, q := range []Query{
+		{Artist: "Unknown Artist", Title: "One More Time", Album: "Discovery"},
+		{Artist: "Daft Punk", Title: "One More Time", Album: "Unknown Album"},
+		{Artist: "Unknown Artist", Title: "One More Time (Remastered 2021)", Album: "Unknown Album"},
+	} {
+		if got := score(q, c); got != 1 {
+			t.Errorf("score(%+v) = %v, want 1", q, got)
+		}
+	}
+	if got := score(Query{Artist: "Someone Else", Title: "One More Time"}, c); got >= MinConfidence {
+		t.Errorf("another artist scored %v, want below %v", got, MinConfidence)
+	}
+}
+
+func TestScoreDuration(t *testing.T) {
+	q := Query{Artist: "The Placeholders", Title: "Paper Lanterns", Duration: 178}
+	tests := []struct {
+		duration float64
+		want     float64
+	}{
+		{duration: 178, want: 1},
+		{duration: 178 + DurationTolerance, want: 1},
+		{duration: 178 - DurationTolerance, want: 1},
+		{duration: 178 + DurationTolerance + 0.5, want: 0},
+		{duration: 150, want: 0},
+		// An unknown length neither helps nor rules out.
+		{duration: 0, want: 1},
+	}
+	for _, tt := range tests {
+		c := Candidate{Artist: q.Artist, Title: q.Title, Duration: tt.duration}
+		if got := score(q, c); got != tt.want {
+			t.Errorf("score with duration %v = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestScoreFeaturedArtist(t *testing.T) {
+	q := Query{Artist: "The Placeholders", Title: "Paper Lanterns"}
+	for _, c := range []Candidate{
+		{Artist: "The Placeholders feat. Guest Singer", Title: "Paper Lanterns"},
+		{Artist: "The Placeholders & Guest Singer", Title: "Paper Lanterns"},
+		{Artist: "The Placeholders", Title: "Paper Lanterns (feat. Guest Singer)"},
+	} {
+		if got := score(q, c); got < MinConfidence {
+			t.Errorf("score(%q, %q) = %v, want at least %v", c.Artist, c.Title, got, MinConfidence)
+		}
+	}
+	if got := similarity("placeholders and guest singer", "placeholders"); got < 0.8 {
+		t.Errorf("similarity of a joint credit = %v, want at least 0.8", got)
+	}
+	if got := similarity("placeholders", "placebo"); got >= 0.8 {
+		t.Errorf("similarity of different artists = %v, want below 0.8", got)
+	}
+}
+
+func TestMatchTagsRoundTrip(t *testing.T) {
+	for _, m := range []Match{
+		{Provider: "lrclib", ID: "12345", Confidence: 0.87},
+		{Provider: "mirror", Confidence: 1},
+		{},
+	} {
+		doc := ParseDocument(matchTags(m) + "[00:01.00]line\n")
+		if got := matchFromTags(doc.Tags); got != m {
+			t.Errorf("round trip of %+v gave %+v", m, got)
+		}
+	}
+}
+
+func TestSaveFetchedPlain(t *testing.T) {
+	dir := t.TempDir()
+	match := Match{Provider: "lrclib", ID: "7", Confidence: 0.92}
+	text := "[Intro]\nFirst line\n\n[Chorus: The Placeholders]\nSecond line"
+
+	saved, err := SaveFetched("01 - Paper Lanterns", dir, Fetched{Plain: text}, match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, found := LoadByName("01 - Paper Lanterns", dir)
+	if !found {
+		t.Fatal("saved lyrics not found")
+	}
+	if loaded.Path != saved.Path || loaded.Match != match {
+		t.Errorf("loaded %s with %+v, want %s with %+v", loaded.Path, loaded.Match, saved.Path, match)
+	}
+	if loaded.Plain != text || saved.Plain != text || len(loaded.Lines) != 0 {
+		t.Errorf("loaded plain %q, saved %q, want %q", loaded.Plain, saved.Plain, text)
+	}
+}
@@ -0,0 +1,80 @@
+package lyrics
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AdjustOffset changes the offset by delta seconds, the way a changed
+// [offset:] tag would: a positive delta shows the lines earlier. The lines
+// keep the file's timing, so moving them before the start and back loses
+// nothing.
+func (l *Lyrics) AdjustOffset(delta float64) {
+	l.Offset = math.Round((l.Offset+delta)*1000) / 1000
+}
+
+// WriteOffset sets the [offset:] tag of the LRC file at path to offset
+// seconds. Only the tag's lines change: the last tag, the one ParseDocument
+// honours, is rewritten in place and any earlier ones are removed, a new
+// tag goes at the top, and a zero offset removes them all.
+func WriteOffset(path string, offset float64) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	bom := ""
+	if strings.HasPrefix(content, "\ufeff") {
+		bom, content = "\ufeff", strings.TrimPrefix(content, "\ufeff")
+	}
+
+	tag := ""
+	if ms := int(math.Round(offset * 1000)); ms != 0 {
+		tag = "[offset:" + formatOffsetMillis(ms) + "]"
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	last := -1
+	for i, line := range lines {
+		if name, _, ok := parseTag(strings.TrimSpace(line)); !ok || name != "offset" {
+			continue
+		}
+		if last >= 0 {
+			lines[last] = ""
+		}
+		last = i
+	}
+	switch {
+	case last >= 0 && tag == "":
+		lines[last] = ""
+	case last >= 0:
+		line := lines[last]
+		lines[last] = tag + line[len(strings.TrimRight(line, "\r\n")):]
+	case tag == "":
+		return nil
+	default:
+		lines = append([]string{tag + newline}, lines...)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(bom+strings.Join(lines, "")), info.Mode().Perm())
+}
+
+// formatOffsetMillis writes an offset the way LRC editors do, with an
+// explicit sign.
+func formatOffsetMillis(ms int) string {
+	if ms > 0 {
+		return "+" + strconv.Itoa(ms)
+	}
+	return strconv.Itoa(ms)
+}
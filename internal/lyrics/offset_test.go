@@ -0,0 +1,106 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		offset  float64
+		want    string
+	}{
+		{
+			name:    "new tag",
+			content: "[ti:Song]\r\n[00:01.00]a\r\n",
+			offset:  0.5,
+			want:    "[offset:+500]\r\n[ti:Song]\r\n[00:01.00]a\r\n",
+		},
+		{
+			name:    "last tag rewritten, earlier ones removed",
+			content: "[offset:+100]\n[ti:Song]\n[offset:-200]\n[00:01.00]a\n",
+			offset:  -0.3,
+			want:    "[ti:Song]\n[offset:-300]\n[00:01.00]a\n",
+		},
+		{
+			name:    "zero removes every tag",
+			content: "[offset:+100]\n[offset:+200]\n[00:01.00]a\n",
+			offset:  0,
+			want:    "[00:01.00]a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "song.lrc")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteOffset(path, tt.offset); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if doc := ParseDocument(string(got)); !closeTo(doc.Offset, tt.offset) {
+				t.Errorf("parsed offset %v, want %v", doc.Offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestAdjustOffsetMatchesParse(t *testing.T) {
+	content := "[00:00.20]a\n[00:10.00]<00:10.00>b <00:10.50>c<00:11.00>"
+	ly := Fetched{Synced: content}.Lyrics()
+	ly.AdjustOffset(0.5)
+	want := ParseDocument("[offset:+500]\n" + content).Lines
+	for i, line := range ly.Lines {
+		if got := ly.Position(line.Time); !closeTo(got, want[i].Time) {
+			t.Errorf("line %d at %v, want %v", i, got, want[i].Time)
+		}
+		for j, w := range line.Words {
+			if !closeTo(ly.Position(w.Start), want[i].Words[j].Start) || !closeTo(ly.Position(w.End), want[i].Words[j].End) {
+				t.Errorf("line %d word %d = %+v, want %+v", i, j, w, want[i].Words[j])
+			}
+		}
+	}
+}
+
+func TestAdjustOffsetPastStart(t *testing.T) {
+	content := "[00:00.20]a\n[00:10.00]<00:10.00>b <00:10.50>c<00:11.00>"
+	ly := Fetched{Synced: content}.Lyrics()
+	original := Fetched{Synced: content}.Lyrics()
+
+	// Everything moves before the start and back again.
+	ly.AdjustOffset(20)
+	if got := ly.Position(ly.Lines[1].Words[1].End); got != 0 {
+		t.Errorf("last word ends at %v, want 0", got)
+	}
+	if end := ly.Lines[1].Words[1].End; end == 0 {
+		t.Error("last word lost its end")
+	}
+	ly.AdjustOffset(-20)
+
+	if ly.Offset != 0 {
+		t.Errorf("offset = %v, want 0", ly.Offset)
+	}
+	for i, line := range ly.Lines {
+		if !closeTo(line.Time, original.Lines[i].Time) {
+			t.Errorf("line %d at %v, want %v", i, line.Time, original.Lines[i].Time)
+		}
+		for j, w := range line.Words {
+			if w != original.Lines[i].Words[j] {
+				t.Errorf("line %d word %d = %+v, want %+v", i, j, w, original.Lines[i].Words[j])
+			}
+		}
+	}
+	if at := ly.At(0.2); !closeTo(at, 0.2) {
+		t.Errorf("At(0.2) = %v with no offset", at)
+	}
+}
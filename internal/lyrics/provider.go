@@ -0,0 +1,175 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// UserAgent identifies the player to lyrics services. main sets the
+// version.
+var UserAgent = "StellePlayer/dev"
+
+// httpClient is shared by every online provider so connections are reused
+// and no request hangs the lookup.
+var httpClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// Query describes the song lyrics are wanted for.
+type Query struct {
+	Artist   string
+	Title    string
+	Album    string
+	Duration float64
+
+	// Name is the base name the lyrics are stored under in the lyrics
+	// folder, see LoadByName.
+	Name string
+
+	// FilePath is the song's own audio file, for sidecar files and tags.
+	// It is empty for songs sharing a file, such as CUE tracks.
+	FilePath string
+
+	// Embedded is the text of the file's lyrics tag, as read by the scan.
+	Embedded string
+}
+
+// Candidate is one set of lyrics a provider found.
+type Candidate struct {
+	// Provider is the name of the provider that found it and ID what that
+	// provider's Get takes to fetch it again.
+	Provider string
+	ID       string
+
+	// What the provider says the lyrics belong to, when it knows.
+	Artist   string
+	Title    string
+	Album    string
+	Duration float64
+
+	// Confidence is how sure the provider is that this is the song, from 0
+	// to 1. Files the user keeps score 1.
+	Confidence float64
+
+	Lyrics Lyrics
+
+	// Fetched is the downloaded text, to be stored with SaveFetched. It is
+	// empty for lyrics that are already on disk or in the file.
+	Fetched Fetched
+}
+
+// Provider is a source of lyrics.
+type Provider interface {
+	// Name identifies the provider in candidates and messages.
+	Name() string
+
+	// Search returns the candidates for a song, best first, including
+	// doubtful ones. No match is not an error.
+	Search(ctx context.Context, q Query) ([]Candidate, error)
+
+	// Get fetches the candidate with the given ID again.
+	Get(ctx context.Context, id string) (Candidate, error)
+}
+
+// Chain asks its providers in order.
+type Chain []Provider
+
+// Find returns the best candidate of the first provider that has lyrics
+// for the song. Failing providers are skipped, and so are candidates below
+// MinConfidence.
+func (c Chain) Find(ctx context.Context, q Query) (Candidate, bool) {
+	for _, p := range c {
+		candidates, err := p.Search(ctx, q)
+		if err != nil {
+			continue
+		}
+		var confident []Candidate
+		for _, candidate := range candidates {
+			if candidate.Confidence >= MinConfidence {
+				confident = append(confident, candidate)
+			}
+		}
+		if best, ok := bestCandidate(confident); ok {
+			return best, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// Search gathers the candidates of every provider, in chain order, for the
+// user to choose from. It fails only when every provider failed.
+func (c Chain) Search(ctx context.Context, q Query) ([]Candidate, error) {
+	var all []Candidate
+	var lastErr error
+	failed := 0
+	for _, p := range c {
+		candidates, err := p.Search(ctx, q)
+		if err != nil {
+			lastErr = err
+			failed++
+			continue
+		}
+		all = append(all, candidates...)
+	}
+	if failed > 0 && failed == len(c) {
+		return nil, lastErr
+	}
+	return all, nil
+}
+
+// bestCandidate prefers synced lyrics, then plain text, then an
+// instrumental marker. Candidates are taken to be sorted best first.
+func bestCandidate(candidates []Candidate) (Candidate, bool) {
+	for _, c := range candidates {
+		if len(c.Lyrics.Lines) > 0 {
+			return c, true
+		}
+	}
+	for _, c := range candidates {
+		if c.Lyrics.Plain != "" {
+			return c, true
+		}
+	}
+	for _, c := range candidates {
+		if c.Lyrics.Instrumental {
+			return c, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// getJSON fetches url and decodes its JSON body into v.
+func getJSON(ctx context.Context, url string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,281 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// lyricsServer serves canned JSON by path and records the queries made.
+type lyricsServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newLyricsServer(t *testing.T, handle func(path string, query url.Values) (any, int)) *lyricsServer {
+	s := &lyricsServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.RequestURI())
+		s.mu.Unlock()
+		if r.Header.Get("User-Agent") != UserAgent {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), UserAgent)
+		}
+		body, status := handle(r.URL.Path, r.URL.Query())
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *lyricsServer) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+var lrclibQuery = Query{Artist: "The Placeholders", Title: "Paper Lanterns", Album: "Test Pressings", Duration: 178}
+
+func lrclibRecord(id int, artist, title string) map[string]any {
+	return map[string]any{
+		"id":           id,
+		"artistName":   artist,
+		"trackName":    title,
+		"albumName":    "Test Pressings",
+		"duration":     178.0,
+		"syncedLyrics": "[00:01.00]line",
+	}
+}
+
+func TestLRCLIBExactMatch(t *testing.T) {
+	s := newLyricsServer(t, func(path string, q url.Values) (any, int) {
+		if path != "/api/get" {
+			return nil, http.StatusInternalServerError
+		}
+		if q.Get("artist_name") != lrclibQuery.Artist || q.Get("track_name") != lrclibQuery.Title ||
+			q.Get("album_name") != lrclibQuery.Album || q.Get("duration") != "178" {
+			t.Errorf("get query = %v", q)
+		}
+		return lrclibRecord(7, lrclibQuery.Artist, lrclibQuery.Title), http.StatusOK
+	})
+
+	candidates, err := NewLRCLIB(s.URL).Search(context.Background(), lrclibQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "7" || candidates[0].Confidence != 1 || len(candidates[0].Lyrics.Lines) != 1 {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+	if got := s.requested(); len(got) != 1 {
+		t.Errorf("requests = %v, want only the exact lookup", got)
+	}
+}
+
+func TestLRCLIBArtistTitleSearch(t *testing.T) {
+	s := newLyricsServer(t, func(path string, q url.Values) (any, int) {
+		switch {
+		case path == "/api/get":
+			return map[string]any{"message": "not found"}, http.StatusNotFound
+		case path == "/api/search" && q.Get("track_name") != "":
+			if q.Get("artist_name") != lrclibQuery.Artist || q.Get("album_name") != lrclibQuery.Album {
+				t.Errorf("search query = %v", q)
+			}
+			return []any{lrclibRecord(3, lrclibQuery.Artist, lrclibQuery.Title)}, http.StatusOK
+		}
+		t.Errorf("unexpected request %s?%s", path, q.Encode())
+		return nil, http.StatusInternalServerError
+	})
+
+	candidates, err := NewLRCLIB(s.URL).Search(context.Background(), lrclibQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "3" || candidates[0].Confidence < MinConfidence {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+}
+
+func TestLRCLIBTitleFallback(t *testing.T) {
+	s := newLyricsServer(t, func(path string, q url.Values) (any, int) {
+		switch {
+		case path == "/api/get":
+			return nil, http.StatusNotFound
+		case path == "/api/search" && q.Get("q") != "":
+			if q.Get("q") != lrclibQuery.Title {
+				t.Errorf("fallback query = %v", q)
+			}
+			// 1 was already found by the first search.
+			return []any{
+				lrclibRecord(1, "Someone Else", lrclibQuery.Title),
+				lrclibRecord(2, lrclibQuery.Artist, lrclibQuery.Title),
+			}, http.StatusOK
+		case path == "/api/search":
+			return []any{lrclibRecord(1, "Someone Else", lrclibQuery.Title)}, http.StatusOK
+		}
+		return nil, http.StatusInternalServerError
+	})
+
+	candidates, err := NewLRCLIB(s.URL).Search(context.Background(), lrclibQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates %+v, want 2 without duplicates", len(candidates), candidates)
+	}
+	if candidates[0].ID != "2" || candidates[1].ID != "1" || candidates[0].Confidence <= candidates[1].Confidence {
+		t.Errorf("order = %s (%.2f), %s (%.2f), want the matching artist first",
+			candidates[0].ID, candidates[0].Confidence, candidates[1].ID, candidates[1].Confidence)
+	}
+}
+
+func TestHTTPSearchResultsPath(t *testing.T) {
+	s := newLyricsServer(t, func(path string, q url.Values) (any, int) {
+		if q.Get("a") != "The Placeholders" || q.Get("t") != "Paper Lanterns" || q.Get("d") != "178" {
+			t.Errorf("search query = %v", q)
+		}
+		return map[string]any{"data": map[string]any{"items": []any{
+			map[string]any{
+				"uid":   42,
+				"track": map[string]any{"artist": "The Placeholders", "name": "Paper Lanterns", "length": 178.4},
+				"lrc":   "[00:01.00]one\n[00:02.00]two",
+				"text":  "one\ntwo",
+			},
+			"not an object",
+		}}}, http.StatusOK
+	})
+
+	p, err := NewHTTP(HTTPConfig{
+		SearchURL: s.URL + "/search?a={artist}&t={title}&d={duration}",
+		Results:   "data.items",
+		Fields: HTTPFields{
+			ID:       "uid",
+			Artist:   "track.artist",
+			Title:    "track.name",
+			Duration: "track.length",
+			Synced:   "lrc",
+			Plain:    "text",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := lrclibQuery
+	q.Duration = 177.6
+	candidates, err := p.Search(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1", len(candidates))
+	}
+	c := candidates[0]
+	if c.Provider != p.Name() || c.ID != "42" || c.Artist != "The Placeholders" || c.Title != "Paper Lanterns" || c.Duration != 178.4 {
+		t.Errorf("candidate = %+v", c)
+	}
+	if len(c.Lyrics.Lines) != 2 || c.Fetched.Plain != "one\ntwo" || c.Confidence != 1 {
+		t.Errorf("lyrics = %+v, confidence %v", c.Lyrics, c.Confidence)
+	}
+}
+
+func TestHTTPSingleObjectAndGet(t *testing.T) {
+	s := newLyricsServer(t, func(path string, q url.Values) (any, int) {
+		record := lrclibRecord(5, lrclibQuery.Artist, lrclibQuery.Title)
+		record["instrumental"] = true
+		switch path {
+		case "/lookup", "/records/a b":
+			return record, http.StatusOK
+		}
+		return nil, http.StatusNotFound
+	})
+
+	p, err := NewHTTP(HTTPConfig{
+		Name:      "mirror",
+		SearchURL: s.URL + "/lookup?artist={artist}",
+		GetURL:    s.URL + "/records/{id}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates, err := p.Search(context.Background(), lrclibQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "5" || !candidates[0].Fetched.Instrumental || candidates[0].Provider != "mirror" {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+
+	c, err := p.Get(context.Background(), "a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "5" || c.Title != lrclibQuery.Title {
+		t.Errorf("got %+v", c)
+	}
+	if _, err := p.Get(context.Background(), "missing"); err == nil {
+		t.Error("Get of a missing record succeeded")
+	}
+}
+
+// stubProvider answers Search with fixed candidates or an error.
+type stubProvider struct {
+	name       string
+	candidates []Candidate
+	err        error
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) Search(context.Context, Query) ([]Candidate, error) {
+	return p.candidates, p.err
+}
+
+func (p stubProvider) Get(context.Context, string) (Candidate, error) {
+	return Candidate{}, errors.New("not supported")
+}
+
+func TestChainFind(t *testing.T) {
+	synced := Lyrics{Lines: []Line{{Time: 1, Text: "line"}}}
+	chain := Chain{
+		stubProvider{name: "down", err: errors.New("connection refused")},
+		stubProvider{name: "doubtful", candidates: []Candidate{{Provider: "doubtful", Confidence: 0.5, Lyrics: synced}}},
+		stubProvider{name: "empty"},
+		stubProvider{name: "good", candidates: []Candidate{
+			{Provider: "good", ID: "plain", Confidence: 0.9, Lyrics: Lyrics{Plain: "line"}},
+			{Provider: "good", ID: "synced", Confidence: 0.8, Lyrics: synced},
+		}},
+		stubProvider{name: "later", candidates: []Candidate{{Provider: "later", Confidence: 1, Lyrics: synced}}},
+	}
+
+	found, ok := chain.Find(context.Background(), Query{})
+	if !ok {
+		t.Fatal("nothing found")
+	}
+	if found.Provider != "good" || found.ID != "synced" {
+		t.Errorf("found %s/%s, want the synced lyrics of good", found.Provider, found.ID)
+	}
+
+	if _, ok := (Chain{stubProvider{name: "down", err: errors.New("timeout")}}).Find(context.Background(), Query{}); ok {
+		t.Error("a chain of failing providers found lyrics")
+	}
+}
+
+func TestChainSearchFails(t *testing.T) {
+	chain := Chain{
+		stubProvider{name: "a", err: errors.New("a failed")},
+		stubProvider{name: "b", err: errors.New("b failed")},
+	}
+	if _, err := chain.Search(context.Background(), Query{}); err == nil {
+		t.Error("Search succeeded with every provider failing")
+	}
+	chain = append(chain, stubProvider{name: "c"})
+	if _, err := chain.Search(context.Background(), Query{}); err != nil {
+		t.Errorf("Search failed with one provider working: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"Player/internal/archive"
+)
+
+// entryPath is the path a song inside an archive is listed under: the
+// archive's path followed by the entry name, as if the archive were a
+// folder.
+func entryPath(archivePath, name string) string {
+	return filepath.Join(archivePath, filepath.FromSlash(name))
+}
+
+// archiveAudioEntries lists the entries of an archive with one of exts, in
+// name order so tracks come out as they would from a folder.
+func archiveAudioEntries(archivePath string, exts map[string]bool) ([]string, error) {
+	r, err := archive.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var names []string
+	for _, entry := range r.Entries() {
+		if exts[strings.ToLower(path.Ext(entry.Name))] {
+			names = append(names, entry.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadArchiveTracks probes the audio entries of an archive, which is
+// opened once for all of them. Entries that fail are passed to skipped
+// under their listed path; the error reports an archive that can't be
+// opened.
+func loadArchiveTracks(archivePath string, exts map[string]bool, opts Options, skipped func(path string, err error)) ([]Metadata, error) {
+	names, err := archiveAudioEntries(archivePath, exts)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	r, err := archive.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var tracks []Metadata
+	for _, name := range names {
+		meta, err := extractEntryMetadata(r, archivePath, name, opts)
+		if err != nil {
+			skipped(entryPath(archivePath, name), err)
+			continue
+		}
+		tracks = append(tracks, meta)
+	}
+	return tracks, nil
+}
+
+// extractEntryMetadata probes an archive entry and fills in inferred fields
+// and overrides, like extractMetadata does for plain files.
+func extractEntryMetadata(r *archive.Reader, archivePath, name string, opts Options) (Metadata, error) {
+	meta, err := probeEntry(r, archivePath, name)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if fields, ok := InferFromPath(meta.FilePath, opts.Roots, opts.PathPatterns); ok {
+		applyInferred(&meta, fields)
+	}
+	if opts.Overrides != nil {
+		opts.Overrides.apply(&meta)
+	}
+
+	return meta, nil
+}
+
+// probeEntry reads an archive entry's tags and streams by piping the entry
+// into ffprobe, which only reads as much as it needs. Nothing is extracted
+// during the scan: formats that keep their index at the end, such as some
+// M4A files, may come back without a duration, which ProbeDuration finds
+// once the entry is extracted to play.
+func probeEntry(r *archive.Reader, archivePath, name string) (Metadata, error) {
+	rc, err := r.Open(name)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer rc.Close()
+
+	const input = "pipe:0"
+	cmd := exec.Command("ffprobe",
+		"-show_format", "-show_streams", "-show_chapters",
+		"-of", "json",
+		"-i", input,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = rc
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Same shape as the errors of ffmpeg.Probe, so probeError applies.
+		return Metadata{}, probeError(input, fmt.Errorf("[%s] %w", stderr.String(), err))
+	}
+	meta, err := parseProbe(stdout.String(), path.Base(name))
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	meta.FilePath = entryPath(archivePath, name)
+	meta.Archive = archivePath
+	meta.ArchiveEntry = name
+	return meta, nil
+}
+
+// ProbeDuration returns the length in seconds of the audio file at
+// filePath, e.g. the extract of an archive entry the scan couldn't time.
+func ProbeDuration(filePath string) (float64, error) {
+	meta, err := probeFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return meta.Duration, nil
+}
+
+// LocalPath returns a path on disk holding the song's audio: the file
+// itself, or the cached extract of an archive entry.
+func LocalPath(meta Metadata) (string, error) {
+	if !meta.InArchive() {
+		return meta.FilePath, nil
+	}
+	return archive.Extract(meta.Archive, meta.ArchiveEntry)
+}
+
+// maxArchiveCoverSize bounds the cover images read out of archives.
+const maxArchiveCoverSize = 16 << 20
+
+// findArchiveArt looks for a folder image next to an entry inside its
+// archive and returns its contents.
+func findArchiveArt(meta Metadata) ([]byte, bool) {
+	r, err := archive.Open(meta.Archive)
+	if err != nil {
+		return nil, false
+	}
+	dir := path.Dir(meta.ArchiveEntry)
+	names := make(map[string]string)
+	for _, entry := range r.Entries() {
+		if path.Dir(entry.Name) == dir {
+			names[strings.ToLower(path.Base(entry.Name))] = entry.Name
+		}
+	}
+	r.Close()
+
+	for _, candidate := range coverFileNames {
+		if name, ok := names[candidate]; ok {
+			data, err := archive.ReadFile(meta.Archive, name, maxArchiveCoverSize)
+			if err == nil {
+				return data, true
+			}
+		}
+	}
+	return nil, false
+}
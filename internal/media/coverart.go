@@ -0,0 +1,112 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// coverFileNames lists the folder images looked up next to a track, in order
+// of preference. Matching is case-insensitive.
+var coverFileNames = []string{
+	"cover.jpg", "cover.jpeg", "cover.png",
+	"folder.jpg", "folder.jpeg", "folder.png",
+	"front.jpg", "front.jpeg", "front.png",
+	"album.jpg", "album.jpeg", "album.png",
+}
+
+// ErrNoCoverArt is returned by CoverArt when neither embedded art nor a
+// folder image is available.
+var ErrNoCoverArt = fmt.Errorf("no cover art found")
+
+// CoverArt returns the encoded cover image for a track. Embedded art
+// (ID3 APIC, FLAC PICTURE, MP4 covr) takes priority over folder images such
+// as folder.jpg or cover.png next to the track. For a track inside an
+// archive, images next to it in the archive are tried first.
+func CoverArt(meta Metadata) ([]byte, error) {
+	if meta.HasEmbeddedArt {
+		if local, err := LocalPath(meta); err == nil {
+			if data, err := extractEmbeddedArt(local); err == nil && len(data) > 0 {
+				return data, nil
+			}
+		}
+	}
+
+	if meta.InArchive() {
+		if data, ok := findArchiveArt(meta); ok {
+			return data, nil
+		}
+	}
+	if path := findFolderArt(filepath.Dir(meta.FilePath)); path != "" {
+		return os.ReadFile(path)
+	}
+
+	return nil, ErrNoCoverArt
+}
+
+// CoverArtSource returns a key identifying where a track's art comes from,
+// so tracks sharing a folder image or an album can share a cached render.
+func CoverArtSource(meta Metadata) string {
+	if meta.HasEmbeddedArt {
+		if meta.Album != "" && meta.Album != "Unknown Album" {
+			artist := meta.AlbumArtist
+			if artist == "" {
+				artist = meta.Artist
+			}
+			return "album:" + strings.ToLower(artist+"\x00"+meta.Album)
+		}
+		return "file:" + meta.FilePath
+	}
+	return "dir:" + filepath.Dir(meta.FilePath)
+}
+
+func findFolderArt(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		names[strings.ToLower(entry.Name())] = entry.Name()
+	}
+
+	for _, candidate := range coverFileNames {
+		if name, ok := names[candidate]; ok {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// extractEmbeddedArt decodes the attached picture stream to PNG through
+// ffmpeg, so callers only need a PNG decoder whatever the original format.
+func extractEmbeddedArt(filePath string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "quiet",
+		"-i", filePath,
+		"-map", "0:v:0",
+		"-frames:v", "1",
+		"-c:v", "png",
+		"-f", "image2pipe",
+		"pipe:1",
+	)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("extract cover art: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,327 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// cueExt is the extension of CUE sheets picked up during scans.
+const cueExt = ".cue"
+
+// CueSheet is a parsed CUE sheet describing the tracks of one or more
+// audio files, typically a whole album ripped to a single FLAC or APE.
+type CueSheet struct {
+	Path       string
+	Title      string
+	Performer  string
+	Songwriter string
+	Genre      string
+	Date       string
+	Files      []CueFile
+}
+
+// CueFile is one FILE entry and the tracks stored in it.
+type CueFile struct {
+	Name   string
+	Tracks []CueTrack
+}
+
+// CueTrack is one TRACK entry. Times are seconds from the start of the
+// file; Pregap is INDEX 00 when the sheet has one.
+type CueTrack struct {
+	Number     int
+	Title      string
+	Performer  string
+	Songwriter string
+	ISRC       string
+	Start      float64
+	Pregap     float64
+	HasPregap  bool
+	hasStart   bool
+	audio      bool
+}
+
+// LoadCueSheet reads and parses the CUE sheet at path.
+func LoadCueSheet(path string) (*CueSheet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := ParseCueSheet(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	sheet.Path = path
+	return sheet, nil
+}
+
+// ParseCueSheet parses the contents of a CUE sheet. Sheets written by
+// older rippers are often Latin-1 rather than UTF-8, so invalid UTF-8 is
+// decoded as Latin-1. Data tracks are dropped.
+func ParseCueSheet(data []byte) (*CueSheet, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := string(data)
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+
+	sheet := &CueSheet{}
+	var file *CueFile
+	var track *CueTrack
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := splitCueLine(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		arg := func(i int) string {
+			if i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "REM":
+			switch strings.ToUpper(arg(1)) {
+			case "GENRE":
+				sheet.Genre = arg(2)
+			case "DATE":
+				sheet.Date = arg(2)
+			}
+		case "TITLE":
+			if track != nil {
+				track.Title = arg(1)
+			} else {
+				sheet.Title = arg(1)
+			}
+		case "PERFORMER":
+			if track != nil {
+				track.Performer = arg(1)
+			} else {
+				sheet.Performer = arg(1)
+			}
+		case "SONGWRITER":
+			if track != nil {
+				track.Songwriter = arg(1)
+			} else {
+				sheet.Songwriter = arg(1)
+			}
+		case "ISRC":
+			if track != nil {
+				track.ISRC = arg(1)
+			}
+		case "FILE":
+			sheet.Files = append(sheet.Files, CueFile{Name: arg(1)})
+			file = &sheet.Files[len(sheet.Files)-1]
+			track = nil
+		case "TRACK":
+			if file == nil {
+				return nil, fmt.Errorf("line %d: TRACK before FILE", lineNo)
+			}
+			number, err := strconv.Atoi(arg(1))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad track number %q", lineNo, arg(1))
+			}
+			file.Tracks = append(file.Tracks, CueTrack{
+				Number: number,
+				audio:  strings.EqualFold(arg(2), "AUDIO"),
+			})
+			track = &file.Tracks[len(file.Tracks)-1]
+		case "INDEX":
+			if track == nil {
+				return nil, fmt.Errorf("line %d: INDEX outside a TRACK", lineNo)
+			}
+			index, err := strconv.Atoi(arg(1))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad index number %q", lineNo, arg(1))
+			}
+			at, err := parseCueTime(arg(2))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			switch index {
+			case 0:
+				track.Pregap, track.HasPregap = at, true
+			case 1:
+				track.Start, track.hasStart = at, true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keep only playable tracks, and files that still have some.
+	files := sheet.Files[:0]
+	for _, f := range sheet.Files {
+		tracks := f.Tracks[:0]
+		for _, t := range f.Tracks {
+			if t.audio && t.hasStart {
+				tracks = append(tracks, t)
+			}
+		}
+		if len(tracks) > 0 {
+			f.Tracks = tracks
+			files = append(files, f)
+		}
+	}
+	sheet.Files = files
+	if len(sheet.Files) == 0 {
+		return nil, fmt.Errorf("no audio tracks")
+	}
+	return sheet, nil
+}
+
+// splitCueLine splits a line into its keyword and arguments, honouring
+// double-quoted arguments that contain spaces.
+func splitCueLine(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = strings.TrimSpace(line[end+2:])
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:end])
+		line = strings.TrimSpace(line[end:])
+	}
+	return fields
+}
+
+// parseCueTime converts MM:SS:FF, with 75 frames per second, to seconds.
+func parseCueTime(value string) (float64, error) {
+	var min, sec, frames int
+	if _, err := fmt.Sscanf(value, "%d:%d:%d", &min, &sec, &frames); err != nil {
+		return 0, fmt.Errorf("bad time %q", value)
+	}
+	return float64(min*60+sec) + float64(frames)/75, nil
+}
+
+// TrackCount returns the number of tracks across all files.
+func (s *CueSheet) TrackCount() int {
+	count := 0
+	for _, f := range s.Files {
+		count += len(f.Tracks)
+	}
+	return count
+}
+
+// resolveFile finds the audio file a FILE entry refers to. Sheets often
+// name the WAV the rip started as while the folder holds the FLAC it was
+// encoded to, so other extensions and letter case are tried as well.
+func (s *CueSheet) resolveFile(name string, exts map[string]bool) (string, error) {
+	dir := filepath.Dir(s.Path)
+	path := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(name, `\`, "/")))
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return path, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	var sameStem string
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.EqualFold(entryName, base) {
+			return filepath.Join(filepath.Dir(path), entryName), nil
+		}
+		ext := strings.ToLower(filepath.Ext(entryName))
+		if sameStem == "" && exts[ext] && strings.EqualFold(strings.TrimSuffix(entryName, filepath.Ext(entryName)), stem) {
+			sameStem = filepath.Join(filepath.Dir(path), entryName)
+		}
+	}
+	if sameStem != "" {
+		return sameStem, nil
+	}
+	return "", fmt.Errorf("audio file %q not found", name)
+}
+
+// cueTracks turns the tracks of one FILE entry into songs. parent holds the
+// probed metadata of the audio file; the sheet's fields win over its tags.
+func (s *CueSheet) cueTracks(file CueFile, parent Metadata) []Metadata {
+	total := s.TrackCount()
+	tracks := make([]Metadata, 0, len(file.Tracks))
+	for i, t := range file.Tracks {
+		meta := parent
+		meta.CueSheet = s.Path
+		meta.CueTrack = t.Number
+		meta.Start = t.Start
+		meta.MusicBrainz = MusicBrainzIDs{}
+		// Chapter times are relative to the file, not to the track, and
+		// the file's lyrics belong to no track in particular.
+		meta.Chapters = nil
+		meta.Lyrics = ""
+
+		// A track runs until the next one starts; its pregap is played as
+		// the tail of this one, as a CD player does.
+		end := parent.Duration
+		if i+1 < len(file.Tracks) {
+			end = file.Tracks[i+1].Start
+		}
+		meta.Duration = end - t.Start
+		if meta.Duration < 0 {
+			meta.Duration = 0
+		}
+
+		meta.Title = t.Title
+		if meta.Title == "" {
+			meta.Title = fmt.Sprintf("Track %02d", t.Number)
+		}
+		if s.Title != "" {
+			meta.Album = s.Title
+		}
+		if s.Performer != "" {
+			meta.AlbumArtist = s.Performer
+			meta.Artist = s.Performer
+		}
+		if t.Performer != "" {
+			meta.Artist = t.Performer
+		}
+		switch {
+		case t.Songwriter != "":
+			meta.Composer = t.Songwriter
+		case s.Songwriter != "":
+			meta.Composer = s.Songwriter
+		}
+		if s.Genre != "" {
+			meta.Genre = s.Genre
+		}
+		if s.Date != "" {
+			meta.Date = s.Date
+			meta.Year = parseYear(s.Date)
+		}
+		meta.TrackNumber = t.Number
+		meta.TrackTotal = total
+		tracks = append(tracks, meta)
+	}
+	return tracks
+}
@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"Player/internal/textmatch"
+)
+
+// DefaultDuplicateTolerance is how far apart, in seconds, the durations of
+// two copies of a track may be. Encoders pad and trim a few frames, and
+// rips differ in the silence they keep at the ends.
+const DefaultDuplicateTolerance = 3.0
+
+// DuplicateOptions control FindDuplicates.
+type DuplicateOptions struct {
+	// Tolerance is the largest duration difference between copies, in
+	// seconds. Zero uses DefaultDuplicateTolerance.
+	Tolerance float64
+
+	// AudioHash also groups tracks whose decoded audio is identical, which
+	// catches copies with different or missing tags, e.g. a FLAC and a WAV
+	// of the same rip. Every track is decoded through ffmpeg, so this is
+	// slow on a large library.
+	AudioHash bool
+
+	// Progress, when set, is called after each track is hashed.
+	Progress func(done, total int)
+}
+
+// DuplicateGroup is a set of tracks that are copies of one another, best
+// quality first.
+type DuplicateGroup struct {
+	Tracks []Metadata
+
+	// ByAudio is set when the audio hash linked some of the tracks.
+	ByAudio bool
+}
+
+// FindDuplicates groups tracks by normalised artist, title and duration
+// and, with AudioHash, by the hash of their decoded audio. Tracks without
+// an artist tag are only matched by hash. Tracks that can't be hashed are
+// returned as issues and only matched by their tags.
+func FindDuplicates(tracks []Metadata, opts DuplicateOptions) ([]DuplicateGroup, []ScanIssue) {
+	tolerance := opts.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultDuplicateTolerance
+	}
+
+	parent := make([]int, len(tracks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		parent[find(a)] = find(b)
+	}
+
+	// Same normalised tags: sort each bucket by duration and link
+	// neighbours within the tolerance.
+	buckets := make(map[string][]int)
+	for i, meta := range tracks {
+		if key, ok := duplicateKey(meta); ok {
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+	for _, bucket := range buckets {
+		sort.Slice(bucket, func(a, b int) bool {
+			return tracks[bucket[a]].Duration < tracks[bucket[b]].Duration
+		})
+		for j := 1; j < len(bucket); j++ {
+			if tracks[bucket[j]].Duration-tracks[bucket[j-1]].Duration <= tolerance {
+				union(bucket[j], bucket[j-1])
+			}
+		}
+	}
+
+	var issues []ScanIssue
+	byAudio := make(map[int]bool)
+	if opts.AudioHash {
+		hashes := make(map[string]int)
+		for i, meta := range tracks {
+			hash, err := AudioHash(meta)
+			if opts.Progress != nil {
+				opts.Progress(i+1, len(tracks))
+			}
+			if err != nil {
+				issues = append(issues, ScanIssue{Path: meta.ID(), Err: err})
+				continue
+			}
+			if first, ok := hashes[hash]; ok {
+				if find(first) != find(i) {
+					byAudio[first], byAudio[i] = true, true
+				}
+				union(i, first)
+				continue
+			}
+			hashes[hash] = i
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range tracks {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	var groups []DuplicateGroup
+	for _, root := range roots {
+		indices := members[root]
+		if len(indices) < 2 {
+			continue
+		}
+		group := DuplicateGroup{}
+		for _, i := range indices {
+			group.Tracks = append(group.Tracks, tracks[i])
+			group.ByAudio = group.ByAudio || byAudio[i]
+		}
+		sort.SliceStable(group.Tracks, func(a, b int) bool {
+			return CompareQuality(group.Tracks[a], group.Tracks[b]) > 0
+		})
+		groups = append(groups, group)
+	}
+	return groups, issues
+}
+
+// duplicateKey returns the normalised artist and title a track is matched
+// on, or false when the track has no usable tags.
+func duplicateKey(meta Metadata) (string, bool) {
+	if meta.Artist == "" || meta.Artist == "Unknown Artist" {
+		return "", false
+	}
+	artist := textmatch.Normalize(meta.Artist)
+	title := textmatch.Normalize(meta.Title)
+	if artist == "" || title == "" {
+		return "", false
+	}
+	return artist + "\x00" + title, true
+}
+
+// CompareQuality orders two copies of a track: positive when a is the
+// better one. Lossless beats lossy; then the higher bit depth and sample
+// rate win for lossless copies and the higher bit rate for lossy ones.
+func CompareQuality(a, b Metadata) int {
+	if a.Lossless != b.Lossless {
+		if a.Lossless {
+			return 1
+		}
+		return -1
+	}
+	if a.Lossless {
+		if c := compareInt(a.BitsPerSample, b.BitsPerSample); c != 0 {
+			return c
+		}
+		if c := compareInt(a.SampleRate, b.SampleRate); c != 0 {
+			return c
+		}
+		return 0
+	}
+	if c := compareInt(a.BitRate, b.BitRate); c != 0 {
+		return c
+	}
+	return compareInt(a.SampleRate, b.SampleRate)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+// audioHashTimeout bounds decoding one track; long mixes take a while.
+const audioHashTimeout = 5 * time.Minute
+
+// AudioHash returns a hash of a track's decoded audio through ffmpeg's hash
+// muxer. Tags, cover art and the container don't affect it; the first
+// audio stream and, for tracks of a CUE sheet, only the track's range are
+// decoded.
+func AudioHash(meta Metadata) (string, error) {
+	local, err := LocalPath(meta)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), audioHashTimeout)
+	defer cancel()
+
+	args := []string{"-v", "error"}
+	if meta.IsCueTrack() {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", meta.Start), "-t", fmt.Sprintf("%.3f", meta.Duration))
+	}
+	args = append(args, "-i", local, "-map", "0:a:0", "-f", "hash", "-hash", "sha256", "-")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		lines := strings.Split(msg, "\n")
+		return "", fmt.Errorf("audio hash: %s", lines[len(lines)-1])
+	}
+
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		if hash, ok := strings.CutPrefix(scanner.Text(), "SHA256="); ok {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("audio hash: no output from ffmpeg")
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package media
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file or directory independently of the path used to
+// reach it.
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIdentity returns the device and inode of path, following symlinks.
+func fileIdentity(path string) (fileID, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileID{}, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, fmt.Errorf("no inode information for %s", path)
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, nil
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package media
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// fileID identifies a file or directory independently of the path used to
+// reach it.
+type fileID struct {
+	volume uint32
+	index  uint64
+}
+
+// fileIdentity returns the volume serial number and file index of path,
+// following symlinks and junctions.
+func fileIdentity(path string) (fileID, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, err
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is required to open directories.
+	h, err := windows.CreateFile(name, 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, err
+	}
+	defer windows.CloseHandle(h)
+
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &info); err != nil {
+		return fileID{}, err
+	}
+	return fileID{
+		volume: info.VolumeSerialNumber,
+		index:  uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow),
+	}, nil
+}
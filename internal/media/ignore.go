@@ -0,0 +1,167 @@
+package media
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the per-directory file listing paths the scan skips. It
+// uses gitignore syntax and applies to the directory it sits in and
+// everything below it.
+const IgnoreFileName = ".stelleignore"
+
+// ignoreRule is one compiled gitignore-style line.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList holds the rules of one .stelleignore, or of the configured
+// exclusions, relative to the directory they apply to.
+type ignoreList struct {
+	base  string
+	rules []ignoreRule
+}
+
+// newIgnoreList compiles gitignore-style patterns relative to base. Blank
+// lines and # comments are skipped; a leading ! re-includes, a trailing /
+// matches directories only, and a / anywhere else anchors the pattern to
+// base instead of matching a name at any depth.
+func newIgnoreList(base string, lines []string) *ignoreList {
+	list := &ignoreList{base: base}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		prefix := "(^|.*/)"
+		if anchored {
+			prefix = "^"
+		}
+		re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		list.rules = append(list.rules, rule)
+	}
+	return list
+}
+
+// loadIgnoreFile reads dir's .stelleignore. A missing file yields nil.
+func loadIgnoreFile(dir string) (*ignoreList, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newIgnoreList(dir, lines), nil
+}
+
+// match reports whether the list decides on path, and if so whether path is
+// ignored. The last matching rule wins.
+func (l *ignoreList) match(path string, isDir bool) (ignored, matched bool) {
+	rel, err := filepath.Rel(l.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for i := len(l.rules) - 1; i >= 0; i-- {
+		rule := l.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			return !rule.negate, true
+		}
+	}
+	return false, false
+}
+
+// isIgnored asks every list in scope, innermost last, so deeper
+// .stelleignore files override their parents.
+func isIgnored(lists []*ignoreList, path string, isDir bool) bool {
+	ignored := false
+	for _, l := range lists {
+		if v, ok := l.match(path, isDir); ok {
+			ignored = v
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates gitignore wildcards: * and ? stay within one path
+// component, ** spans components and [...] is a character class.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
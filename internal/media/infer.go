@@ -0,0 +1,196 @@
+package media
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PathPattern infers tags from a file path, e.g. "{artist}/{album}/{track} - {title}".
+// Each "/"-separated segment of the pattern matches one trailing component
+// of the path inside the library; the last segment matches the file name
+// without its extension.
+type PathPattern struct {
+	raw      string
+	segments int
+	re       *regexp.Regexp
+}
+
+// patternFields maps placeholder names to the expression they match.
+// {_} matches anything and is discarded.
+var patternFields = map[string]string{
+	"artist":      `[^/]+?`,
+	"albumartist": `[^/]+?`,
+	"album":       `[^/]+?`,
+	"title":       `[^/]+?`,
+	"genre":       `[^/]+?`,
+	"track":       `\d+`,
+	"disc":        `\d+`,
+	"year":        `\d{4}`,
+	"_":           `[^/]+?`,
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// CompilePattern parses a path pattern.
+func CompilePattern(pattern string) (PathPattern, error) {
+	pattern = strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+	if pattern == "" {
+		return PathPattern{}, fmt.Errorf("empty path pattern")
+	}
+
+	seen := make(map[string]bool)
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	last := 0
+	for _, loc := range placeholderRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		name := pattern[loc[2]:loc[3]]
+		fieldExpr, ok := patternFields[name]
+		if !ok {
+			return PathPattern{}, fmt.Errorf("path pattern %q: unknown field {%s}", pattern, name)
+		}
+
+		expr.WriteString(literalPattern(pattern[last:loc[0]]))
+		if name == "_" {
+			expr.WriteString("(?:" + fieldExpr + ")")
+		} else {
+			if seen[name] {
+				return PathPattern{}, fmt.Errorf("path pattern %q: {%s} used twice", pattern, name)
+			}
+			seen[name] = true
+			expr.WriteString("(?P<" + name + ">" + fieldExpr + ")")
+		}
+		last = loc[1]
+	}
+	expr.WriteString(literalPattern(pattern[last:]))
+	expr.WriteString("$")
+
+	if len(seen) == 0 {
+		return PathPattern{}, fmt.Errorf("path pattern %q has no fields", pattern)
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return PathPattern{}, fmt.Errorf("path pattern %q: %w", pattern, err)
+	}
+
+	return PathPattern{
+		raw:      pattern,
+		segments: strings.Count(pattern, "/") + 1,
+		re:       re,
+	}, nil
+}
+
+// CompilePatterns parses several patterns, stopping at the first error.
+func CompilePatterns(patterns []string) ([]PathPattern, error) {
+	compiled := make([]PathPattern, 0, len(patterns))
+	for _, p := range patterns {
+		c, err := CompilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// literalPattern quotes pattern text and makes it tolerant of extra spaces
+// around separators, which hand-named files are full of.
+func literalPattern(text string) string {
+	quoted := regexp.QuoteMeta(text)
+	return strings.ReplaceAll(quoted, " ", `\s*`)
+}
+
+func (p PathPattern) String() string { return p.raw }
+
+// Match returns the fields the pattern extracts from relPath, a path
+// relative to the library root as RelPath gives it. Folders above the root
+// are never matched: a pattern with more segments than relPath fails.
+func (p PathPattern) Match(relPath string) (map[string]string, bool) {
+	slashed := filepath.ToSlash(relPath)
+	slashed = strings.TrimSuffix(slashed, filepath.Ext(slashed))
+
+	parts := strings.Split(slashed, "/")
+	if len(parts) < p.segments {
+		return nil, false
+	}
+	tail := strings.Join(parts[len(parts)-p.segments:], "/")
+
+	matches := p.re.FindStringSubmatch(tail)
+	if matches == nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string)
+	for i, name := range p.re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if value := strings.TrimSpace(matches[i]); value != "" {
+			fields[name] = value
+		}
+	}
+	return fields, true
+}
+
+// InferFromPath returns the fields from the first pattern that matches the
+// path of filePath inside roots.
+func InferFromPath(filePath string, roots []string, patterns []PathPattern) (map[string]string, bool) {
+	if len(patterns) == 0 {
+		return nil, false
+	}
+	rel, _ := RelPath(roots, filePath)
+	for _, p := range patterns {
+		if fields, ok := p.Match(rel); ok {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+// RelPath returns filePath relative to the innermost of roots holding it,
+// and that root's index. A path outside every root is returned as it is,
+// with -1.
+func RelPath(roots []string, filePath string) (string, int) {
+	owner, key := -1, filePath
+	for i, root := range roots {
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if owner < 0 || len(rel) < len(key) {
+			owner, key = i, rel
+		}
+	}
+	return key, owner
+}
+
+// applyInferred fills the fields that tags left empty.
+func applyInferred(meta *Metadata, fields map[string]string) {
+	if title, ok := fields["title"]; ok && meta.Title == filepath.Base(meta.FilePath) {
+		meta.Title = title
+	}
+	if artist, ok := fields["artist"]; ok && meta.Artist == "Unknown Artist" {
+		meta.Artist = artist
+	}
+	if album, ok := fields["album"]; ok && meta.Album == "Unknown Album" {
+		meta.Album = album
+	}
+	if albumArtist, ok := fields["albumartist"]; ok && meta.AlbumArtist == "" {
+		meta.AlbumArtist = albumArtist
+	}
+	if genre, ok := fields["genre"]; ok && meta.Genre == "" {
+		meta.Genre = genre
+	}
+	if meta.TrackNumber == 0 {
+		fmt.Sscanf(fields["track"], "%d", &meta.TrackNumber)
+	}
+	if meta.DiscNumber == 0 {
+		fmt.Sscanf(fields["disc"], "%d", &meta.DiscNumber)
+	}
+	if meta.Year == 0 {
+		fmt.Sscanf(fields["year"], "%d", &meta.Year)
+	}
+}
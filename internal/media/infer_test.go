@@ -0,0 +1,70 @@
+package media
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInferFromPathBelowRoot(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "user", "Music")
+	nested := filepath.Join(root, "Live")
+	roots := []string{root, nested}
+
+	full, err := CompilePattern("{artist}/{album}/{track} - {title}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := CompilePattern("{artist} - {title}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		patterns []PathPattern
+		want     map[string]string
+	}{
+		{
+			name:     "deep enough",
+			path:     filepath.Join(root, "Artist", "Album", "01 - Song.mp3"),
+			patterns: []PathPattern{full},
+			want:     map[string]string{"artist": "Artist", "album": "Album", "track": "01", "title": "Song"},
+		},
+		{
+			// "user" and "Music" lie above the root and must not become
+			// the artist and album.
+			name:     "too shallow",
+			path:     filepath.Join(root, "01 - Song.mp3"),
+			patterns: []PathPattern{full},
+		},
+		{
+			name:     "falls through to a shorter pattern",
+			path:     filepath.Join(root, "Artist - Song.mp3"),
+			patterns: []PathPattern{full, short},
+			want:     map[string]string{"artist": "Artist", "title": "Song"},
+		},
+		{
+			// Inside the nested root, its own folder is not part of the path.
+			name:     "innermost root",
+			path:     filepath.Join(nested, "Album", "01 - Song.mp3"),
+			patterns: []PathPattern{full},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InferFromPath(tt.path, roots, tt.patterns)
+			if ok != (tt.want != nil) {
+				t.Fatalf("matched = %v with %v, want %v", ok, got, tt.want != nil)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -2,148 +2,809 @@ package media
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"Player/internal/archive"
+
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
 type Metadata struct {
-	Title      string
-	Artist     string
-	Album      string
-	Duration   float64
-	FilePath   string
-	Bitrate    string
-	Codec      string
-	SampleRate string
-}
-
-func LoadFromDirectory(dir string) ([]Metadata, error) {
-	var tracks []Metadata
-	supportedExts := map[string]bool{
-		".mp3":  true,
-		".m4a":  true,
-		".flac": true,
-		".wav":  true,
-		".ogg":  true,
-		".aac":  true,
-		".opus": true,
-	}
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	Title    string
+	Artist   string
+	Album    string
+	Duration float64
+	FilePath string
+
+	// Technical fields are stored raw; formatting is left to the view.
+	BitRate       int    // bits per second
+	Codec         string // ffprobe codec_name, e.g. "flac", "mp3"
+	SampleRate    int    // Hz
+	Channels      int
+	ChannelLayout string // e.g. "stereo", "5.1(side)"
+	BitsPerSample int
+	Lossless      bool
+
+	// Chapters are the file's chapter marks, e.g. in an M4B audiobook or a
+	// long mix, in order.
+	Chapters []Chapter
+
+	// AudioStreams lists every audio stream in file order. The technical
+	// fields above describe the default one.
+	AudioStreams []AudioStream
+
+	// HasVideo is set for video containers such as concert recordings;
+	// attached cover pictures don't count.
+	HasVideo bool
+
+	// Lyrics is the text of an embedded lyrics tag (ID3 USLT, Vorbis
+	// LYRICS or UNSYNCEDLYRICS, MP4 ©lyr), often LRC. ID3 SYLT frames are
+	// not visible to ffprobe and are read on demand by the lyrics package.
+	Lyrics string
+
+	// HasEmbeddedArt is set when the file carries an attached picture
+	// (ID3 APIC, FLAC PICTURE, MP4 covr).
+	HasEmbeddedArt bool
+
+	// Set for a track of a CUE sheet, which plays the range of FilePath
+	// starting at Start and lasting Duration.
+	CueSheet string
+	CueTrack int
+	Start    float64
+
+	// Set for a file stored in a zip or 7z archive. FilePath is then the
+	// archive path joined with the entry name, which names the song but
+	// doesn't exist on disk.
+	Archive      string
+	ArchiveEntry string
+
+	// Set from the library's Overrides rather than the file.
+	Hidden     bool
+	SortKey    string
+	Overridden bool
+
+	AlbumArtist string
+	Composer    string
+	Genre       string
+	Comment     string
+	Date        string
+	Year        int
+	TrackNumber int
+	TrackTotal  int
+	DiscNumber  int
+	DiscTotal   int
+	MusicBrainz MusicBrainzIDs
+}
+
+// ID identifies the song. It is the file path, extended with the track
+// number for tracks of a CUE sheet, which share their file.
+func (m Metadata) ID() string {
+	if m.CueSheet == "" {
+		return m.FilePath
+	}
+	return fmt.Sprintf("%s#%d", m.FilePath, m.CueTrack)
+}
+
+// IsCueTrack reports whether the song is a track of a CUE sheet rather than
+// a whole file.
+func (m Metadata) IsCueTrack() bool {
+	return m.CueSheet != ""
+}
+
+// InArchive reports whether the song is an entry of an archive.
+func (m Metadata) InArchive() bool {
+	return m.Archive != ""
+}
+
+// Chapter is a named section of a song. Times are in seconds.
+type Chapter struct {
+	Title string
+	Start float64
+	End   float64
+}
+
+// ChapterAt returns the index of the chapter playing at pos, or -1 when the
+// song has no chapters or pos falls before the first.
+func (m Metadata) ChapterAt(pos float64) int {
+	idx := -1
+	for i, ch := range m.Chapters {
+		if ch.Start > pos {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// AudioStream describes one audio stream of a file. Most files have one;
+// video containers often carry several, e.g. per language or commentary.
+type AudioStream struct {
+	Index         int // ffprobe stream index, as passed to ffplay -ast
+	Codec         string
+	Language      string // e.g. "eng"; empty when untagged
+	Title         string
+	SampleRate    int
+	Channels      int
+	ChannelLayout string
+	BitsPerSample int
+	BitRate       int
+	Default       bool
+}
+
+// DefaultAudioStream returns the stream players pick by default: the first
+// flagged as default, or else the first. It is the zero AudioStream when
+// the file has none.
+func (m Metadata) DefaultAudioStream() AudioStream {
+	for _, st := range m.AudioStreams {
+		if st.Default {
+			return st
+		}
+	}
+	if len(m.AudioStreams) > 0 {
+		return m.AudioStreams[0]
+	}
+	return AudioStream{}
+}
+
+// ForStream returns a copy of m whose technical fields describe the audio
+// stream with the given ffprobe index. Unknown indices return m unchanged.
+func (m Metadata) ForStream(index int) Metadata {
+	for _, st := range m.AudioStreams {
+		if st.Index == index {
+			m.useStream(st)
+			break
+		}
+	}
+	return m
+}
+
+// useStream copies a stream's technical fields into m. The container bit
+// rate is kept for audio files, where it is more reliable; in a video file
+// it counts the picture too, so the stream's own rate is used.
+func (m *Metadata) useStream(st AudioStream) {
+	m.Codec = st.Codec
+	m.Lossless = isLosslessCodec(st.Codec)
+	m.SampleRate = st.SampleRate
+	m.Channels = st.Channels
+	m.ChannelLayout = st.ChannelLayout
+	m.BitsPerSample = st.BitsPerSample
+	if m.HasVideo || m.BitRate == 0 {
+		m.BitRate = st.BitRate
+	}
+}
+
+// MusicBrainzIDs holds the MusicBrainz identifiers written by taggers such as Picard.
+type MusicBrainzIDs struct {
+	RecordingID    string
+	ReleaseTrackID string
+	AlbumID        string
+	ArtistID       string
+	AlbumArtistID  string
+	ReleaseGroupID string
+}
+
+// Options control how a library is scanned.
+type Options struct {
+	// PathPatterns fill tags missing from a file using its path. Nil
+	// disables inference.
+	PathPatterns []PathPattern
+
+	// Roots are the library roots the patterns see paths relative to.
+	// LoadFromDirectories sets them to the directories it scans.
+	Roots []string
+
+	// Overrides are applied on top of tags and inferred fields. Nil disables
+	// them.
+	Overrides *Overrides
+
+	// Extensions lists the file extensions scanned, e.g. ".mp3". Nil uses
+	// DefaultExtensions.
+	Extensions []string
+
+	// Exclude holds gitignore-style patterns applied below every root, on
+	// top of any .stelleignore files found during the walk.
+	Exclude []string
+
+	// FollowSymlinks descends into symlinked directories and picks up
+	// symlinked files. Loops are detected and every file is scanned once,
+	// however many paths lead to it.
+	FollowSymlinks bool
+
+	// ScanArchives lists the audio files inside zip and 7z archives as
+	// songs.
+	ScanArchives bool
+
+	// MinDuration drops tracks shorter than this many seconds, e.g. samples
+	// and stems. Zero keeps everything.
+	MinDuration float64
+}
+
+// DefaultExtensions are the formats scanned when Options.Extensions is nil.
+var DefaultExtensions = []string{".mp3", ".m4a", ".flac", ".wav", ".ogg", ".aac", ".opus", ".mkv", ".mp4", ".webm"}
+
+// extensionSet normalises a configured extension list for lookups.
+func extensionSet(exts []string) map[string]bool {
+	if exts == nil {
+		exts = DefaultExtensions
+	}
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
+}
+
+// ScanIssue is a file or directory a scan skipped, with the reason.
+type ScanIssue struct {
+	Path string
+	Err  error
+}
+
+// ScanReport is the result of scanning a library: the tracks that loaded and
+// everything that was skipped along the way.
+type ScanReport struct {
+	Tracks []Metadata
+	Issues []ScanIssue
+}
+
+// LoadFromDirectory probes every supported audio file under dir.
+func LoadFromDirectory(dir string, opts Options) (ScanReport, error) {
+	return LoadFromDirectories([]string{dir}, opts)
+}
+
+// LoadFromDirectories probes every supported audio file under the given
+// roots. Files that fail to probe and directories that can't be read,
+// including whole roots, are recorded in the report and the scan carries on;
+// it is only an error when no root could be read at all.
+//
+// A CUE sheet claims the files it describes, which are then listed as the
+// sheet's tracks instead of as one long song.
+func LoadFromDirectories(dirs []string, opts Options) (ScanReport, error) {
+	if opts.Roots == nil {
+		opts.Roots = dirs
+	}
+	var report ScanReport
+	addIssue := func(path string, err error) {
+		report.Issues = append(report.Issues, ScanIssue{Path: path, Err: err})
+	}
+	keep := func(meta Metadata) {
+		// An unknown duration is kept; only clips known to be short go.
+		if opts.MinDuration > 0 && meta.Duration > 0 && meta.Duration < opts.MinDuration {
+			return
+		}
+		report.Tracks = append(report.Tracks, meta)
+	}
+
+	audioExts := extensionSet(opts.Extensions)
+	exts := extensionSet(opts.Extensions)
+	exts[cueExt] = true
+	if opts.ScanArchives {
+		for _, ext := range archive.Extensions {
+			exts[ext] = true
+		}
+	}
+
+	var files []string
+	err := walkAudioFiles(dirs, opts, exts, func(path string) {
+		files = append(files, path)
+	}, addIssue)
+
+	sheets := make(map[string]*CueSheet)
+	sheetFiles := make(map[string][]string)
+	claimed := make(map[string]bool)
+	for _, path := range files {
+		if !isCueSheet(path) {
+			continue
+		}
+		sheet, err := LoadCueSheet(path)
 		if err != nil {
-			return err
+			addIssue(path, err)
+			continue
 		}
-		if info.IsDir() {
-			return nil
+		resolved := make([]string, len(sheet.Files))
+		for i, f := range sheet.Files {
+			audio, err := sheet.resolveFile(f.Name, audioExts)
+			if err != nil {
+				addIssue(path, err)
+				continue
+			}
+			// A second sheet for the same rip would list every track twice.
+			if !claimed[audio] {
+				claimed[audio] = true
+				resolved[i] = audio
+			}
 		}
+		sheets[path] = sheet
+		sheetFiles[path] = resolved
+	}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		if !supportedExts[ext] {
-			return nil
+	for _, path := range files {
+		switch {
+		case isCueSheet(path):
+			sheet, ok := sheets[path]
+			if !ok {
+				continue
+			}
+			for i, audio := range sheetFiles[path] {
+				if audio == "" {
+					continue
+				}
+				tracks, err := loadCueTracks(sheet, sheet.Files[i], audio, opts)
+				if err != nil {
+					addIssue(audio, err)
+					continue
+				}
+				for _, meta := range tracks {
+					keep(meta)
+				}
+			}
+		case claimed[path]:
+		case opts.ScanArchives && archive.IsArchive(path):
+			tracks, err := loadArchiveTracks(path, audioExts, opts, addIssue)
+			if err != nil {
+				addIssue(path, err)
+				continue
+			}
+			for _, meta := range tracks {
+				keep(meta)
+			}
+		default:
+			meta, err := extractMetadata(path, opts)
+			if err != nil {
+				addIssue(path, err)
+				continue
+			}
+			keep(meta)
+		}
+	}
+
+	return report, err
+}
+
+func isCueSheet(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), cueExt)
+}
+
+// loadCueTracks probes the audio file behind a FILE entry and returns its
+// tracks with overrides applied. Path patterns are not used; the sheet is
+// the better source.
+func loadCueTracks(sheet *CueSheet, file CueFile, audio string, opts Options) ([]Metadata, error) {
+	parent, err := probeFile(audio)
+	if err != nil {
+		return nil, err
+	}
+	tracks := sheet.cueTracks(file, parent)
+	if opts.Overrides != nil {
+		for i := range tracks {
+			opts.Overrides.apply(&tracks[i])
+		}
+	}
+	return tracks, nil
+}
+
+// FindAudioFiles lists the supported audio files under dirs without probing
+// them. Unreadable subdirectories are skipped. With ScanArchives, the audio
+// entries of archives are listed under their archive's path.
+func FindAudioFiles(dirs []string, opts Options) ([]string, error) {
+	audioExts := extensionSet(opts.Extensions)
+	exts := extensionSet(opts.Extensions)
+	if opts.ScanArchives {
+		for _, ext := range archive.Extensions {
+			exts[ext] = true
+		}
+	}
+
+	var files []string
+	err := walkAudioFiles(dirs, opts, exts, func(path string) {
+		if !opts.ScanArchives || !archive.IsArchive(path) {
+			files = append(files, path)
+			return
+		}
+		names, err := archiveAudioEntries(path, audioExts)
+		if err != nil {
+			return
 		}
+		for _, name := range names {
+			files = append(files, entryPath(path, name))
+		}
+	}, func(string, error) {})
+	return files, err
+}
+
+// errSymlinkLoop is reported for a symlink leading back to a directory that
+// is still being scanned.
+var errSymlinkLoop = errors.New("symlink loop: links back to a parent folder")
+
+// walker visits the audio files of a library, honouring exclusions.
+type walker struct {
+	exts    map[string]bool
+	seen    map[string]bool
+	found   func(path string)
+	skipped func(path string, err error)
 
-		meta, err := extractMetadata(path)
+	// Used when following symlinks, where paths are not unique: active
+	// holds the directories on the current branch, visitedDirs and seenIDs
+	// everything scanned so far.
+	follow      bool
+	active      map[fileID]bool
+	visitedDirs map[fileID]bool
+	seenIDs     map[fileID]bool
+}
+
+// walkAudioFiles calls found for every file with one of exts under dirs, root
+// by root in lexical order. A file under several roots is visited once.
+// Entries that can't be read are passed to skipped and the walk continues;
+// the error reports that none of the roots could be read.
+func walkAudioFiles(dirs []string, opts Options, exts map[string]bool, found func(path string), skipped func(path string, err error)) error {
+	w := &walker{
+		exts:    exts,
+		seen:    make(map[string]bool),
+		found:   found,
+		skipped: skipped,
+		follow:  opts.FollowSymlinks,
+	}
+	if w.follow {
+		w.active = make(map[fileID]bool)
+		w.visitedDirs = make(map[fileID]bool)
+		w.seenIDs = make(map[fileID]bool)
+	}
+
+	var firstErr error
+	readable := 0
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		var ignores []*ignoreList
+		if len(opts.Exclude) > 0 {
+			ignores = append(ignores, newIgnoreList(dir, opts.Exclude))
+		}
+		if err := w.walkDir(dir, ignores); err != nil {
+			skipped(dir, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		readable++
+	}
+
+	if readable == 0 && firstErr != nil {
+		return firstErr
+	}
+	return nil
+}
+
+// walkDir visits dir with the ignore lists of its parents in scope. Only a
+// failure to list dir itself is returned.
+func (w *walker) walkDir(dir string, ignores []*ignoreList) error {
+	if w.follow {
+		id, err := fileIdentity(dir)
 		if err != nil {
+			return err
+		}
+		if w.active[id] {
+			return errSymlinkLoop
+		}
+		if w.visitedDirs[id] {
+			// Already scanned through another path.
 			return nil
 		}
-		tracks = append(tracks, meta)
-		return nil
-	})
+		w.visitedDirs[id] = true
+		w.active[id] = true
+		defer delete(w.active, id)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
 
-	return tracks, err
+	own, err := loadIgnoreFile(dir)
+	if err != nil {
+		w.skipped(filepath.Join(dir, IgnoreFileName), err)
+	}
+	if own != nil {
+		ignores = append(ignores[:len(ignores):len(ignores)], own)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		supported := w.exts[strings.ToLower(filepath.Ext(path))]
+		isDir, regular := entry.IsDir(), entry.Type().IsRegular()
+		if w.follow && entry.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(path)
+			if err != nil {
+				// Only broken links that look like audio are worth a report.
+				if supported {
+					w.skipped(path, err)
+				}
+				continue
+			}
+			isDir, regular = info.IsDir(), info.Mode().IsRegular()
+		}
+		if isIgnored(ignores, path, isDir) {
+			continue
+		}
+
+		if isDir {
+			if err := w.walkDir(path, ignores); err != nil {
+				w.skipped(path, err)
+			}
+			continue
+		}
+		if !regular || !supported || !w.markSeen(path) {
+			continue
+		}
+		w.found(path)
+	}
+	return nil
 }
 
-func extractMetadata(filePath string) (Metadata, error) {
-	data, err := ffmpeg.Probe(filePath)
+// markSeen records path and reports whether it is new. When following
+// symlinks files are told apart by identity rather than path.
+func (w *walker) markSeen(path string) bool {
+	if w.seen[path] {
+		return false
+	}
+	w.seen[path] = true
+	if !w.follow {
+		return true
+	}
+
+	id, err := fileIdentity(path)
+	if err != nil {
+		w.skipped(path, err)
+		return false
+	}
+	if w.seenIDs[id] {
+		return false
+	}
+	w.seenIDs[id] = true
+	return true
+}
+
+var losslessCodecs = map[string]bool{
+	"flac":    true,
+	"alac":    true,
+	"ape":     true,
+	"wavpack": true,
+	"tta":     true,
+	"tak":     true,
+	"mlp":     true,
+	"truehd":  true,
+	"mp4als":  true,
+}
+
+func isLosslessCodec(codec string) bool {
+	return losslessCodecs[codec] || strings.HasPrefix(codec, "pcm_")
+}
+
+// parseAudioStream reads one ffprobe audio stream object.
+func parseAudioStream(streamMap map[string]interface{}) AudioStream {
+	var st AudioStream
+	if index, ok := streamMap["index"].(float64); ok {
+		st.Index = int(index)
+	}
+	st.Codec, _ = streamMap["codec_name"].(string)
+	if sampleRate, ok := streamMap["sample_rate"].(string); ok {
+		fmt.Sscanf(sampleRate, "%d", &st.SampleRate)
+	}
+	if channels, ok := streamMap["channels"].(float64); ok {
+		st.Channels = int(channels)
+	}
+	st.ChannelLayout, _ = streamMap["channel_layout"].(string)
+	// Lossless codecs report their depth in bits_per_raw_sample,
+	// PCM reports it in bits_per_sample.
+	if bits, ok := streamMap["bits_per_raw_sample"].(string); ok {
+		fmt.Sscanf(bits, "%d", &st.BitsPerSample)
+	}
+	if bits, ok := streamMap["bits_per_sample"].(float64); ok && st.BitsPerSample == 0 {
+		st.BitsPerSample = int(bits)
+	}
+	if bitrate, ok := streamMap["bit_rate"].(string); ok {
+		fmt.Sscanf(bitrate, "%d", &st.BitRate)
+	}
+	if disposition, ok := streamMap["disposition"].(map[string]interface{}); ok {
+		def, _ := disposition["default"].(float64)
+		st.Default = def == 1
+	}
+	if raw, ok := streamMap["tags"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			value, _ := v.(string)
+			switch strings.ToLower(k) {
+			case "language":
+				if value != "und" {
+					st.Language = value
+				}
+			case "title":
+				st.Title = value
+			case "bps":
+				// Matroska keeps stream bit rates in statistics tags.
+				if st.BitRate == 0 {
+					fmt.Sscanf(value, "%d", &st.BitRate)
+				}
+			}
+		}
+	}
+	return st
+}
+
+// parseChapters reads ffprobe's chapter list. Untitled chapters are
+// numbered.
+func parseChapters(raw []interface{}) []Chapter {
+	var chapters []Chapter
+	for _, c := range raw {
+		chapterMap, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var ch Chapter
+		if start, ok := chapterMap["start_time"].(string); ok {
+			fmt.Sscanf(start, "%f", &ch.Start)
+		}
+		if end, ok := chapterMap["end_time"].(string); ok {
+			fmt.Sscanf(end, "%f", &ch.End)
+		}
+		if raw, ok := chapterMap["tags"].(map[string]interface{}); ok {
+			tags := tagSet{}
+			tags.merge(raw)
+			ch.Title = tags.get("title")
+		}
+		if ch.Title == "" {
+			ch.Title = fmt.Sprintf("Chapter %d", len(chapters)+1)
+		}
+		chapters = append(chapters, ch)
+	}
+	return chapters
+}
+
+// isAttachedPicture reports whether a video stream is embedded cover art
+// rather than actual video.
+func isAttachedPicture(stream map[string]interface{}) bool {
+	disposition, ok := stream["disposition"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	attached, _ := disposition["attached_pic"].(float64)
+	return attached == 1
+}
+
+// errNoAudioStream is returned for files ffprobe can read that hold no audio,
+// e.g. a video or an image saved with an audio extension.
+var errNoAudioStream = errors.New("no audio stream")
+
+// probeError turns an ffprobe failure into its last diagnostic line. The
+// library wraps the whole stderr, banner included, in brackets before the
+// exit status, and ffprobe prefixes the message with the file path.
+func probeError(filePath string, err error) error {
+	msg := err.Error()
+	start := strings.Index(msg, "[")
+	end := strings.LastIndex(msg, "]")
+	if start < 0 || end <= start {
+		return fmt.Errorf("ffprobe: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(msg[start+1:end]), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	last = strings.TrimPrefix(last, filePath+": ")
+	if last == "" {
+		// ffprobe never ran, e.g. it is missing from PATH.
+		return fmt.Errorf("ffprobe: %s", strings.TrimSpace(msg[end+1:]))
+	}
+	return fmt.Errorf("ffprobe: %s", last)
+}
+
+// extractMetadata probes a file and fills in inferred fields and overrides.
+func extractMetadata(filePath string, opts Options) (Metadata, error) {
+	meta, err := probeFile(filePath)
 	if err != nil {
 		return Metadata{}, err
 	}
 
+	if fields, ok := InferFromPath(filePath, opts.Roots, opts.PathPatterns); ok {
+		applyInferred(&meta, fields)
+	}
+	if opts.Overrides != nil {
+		opts.Overrides.apply(&meta)
+	}
+
+	return meta, nil
+}
+
+// probeFile reads the tags and stream details of a file through ffprobe.
+func probeFile(filePath string) (Metadata, error) {
+	data, err := ffmpeg.Probe(filePath, ffmpeg.KwArgs{"show_chapters": ""})
+	if err != nil {
+		return Metadata{}, probeError(filePath, err)
+	}
+	return parseProbe(data, filePath)
+}
+
+// parseProbe builds a song's metadata from ffprobe's JSON output.
+func parseProbe(data, filePath string) (Metadata, error) {
 	var probeData map[string]interface{}
 	if err := json.Unmarshal([]byte(data), &probeData); err != nil {
 		return Metadata{}, err
 	}
 
 	meta := Metadata{
-		FilePath:   filePath,
-		Title:      filepath.Base(filePath),
-		Artist:     "Unknown Artist",
-		Album:      "Unknown Album",
-		Bitrate:    "N/A",
-		Codec:      "N/A",
-		SampleRate: "N/A",
+		FilePath: filePath,
+		Title:    filepath.Base(filePath),
+		Artist:   "Unknown Artist",
+		Album:    "Unknown Album",
 	}
 
+	// Format tags win over stream tags; FLAC and Ogg only carry tags on the
+	// audio stream, so those are merged in as a fallback.
+	tags := tagSet{}
+
 	if format, ok := probeData["format"].(map[string]interface{}); ok {
 		if duration, ok := format["duration"].(string); ok {
 			fmt.Sscanf(duration, "%f", &meta.Duration)
 		}
 		if bitrate, ok := format["bit_rate"].(string); ok {
-			bitrateInt := 0
-			fmt.Sscanf(bitrate, "%d", &bitrateInt)
-			if bitrateInt > 0 {
-				meta.Bitrate = fmt.Sprintf("%d kbps", bitrateInt/1000)
-			}
+			fmt.Sscanf(bitrate, "%d", &meta.BitRate)
 		}
-		if tags, ok := format["tags"].(map[string]interface{}); ok {
-			if title, ok := tags["title"].(string); ok {
-				meta.Title = title
-			}
-			if artist, ok := tags["artist"].(string); ok {
-				meta.Artist = artist
-			}
-			if album, ok := tags["album"].(string); ok {
-				meta.Album = album
-			}
+		if raw, ok := format["tags"].(map[string]interface{}); ok {
+			tags.merge(raw)
 		}
 	}
 
 	if streams, ok := probeData["streams"].([]interface{}); ok {
+		var streamTags []map[string]interface{}
 		for _, stream := range streams {
 			streamMap, ok := stream.(map[string]interface{})
 			if !ok {
 				continue
 			}
-			if codecType, ok := streamMap["codec_type"].(string); !ok || codecType != "audio" {
-				continue
-			}
-			if codecName, ok := streamMap["codec_name"].(string); ok {
-				meta.Codec = strings.ToUpper(codecName)
-			}
-			if sampleRate, ok := streamMap["sample_rate"].(string); ok {
-				sampleRateInt := 0
-				fmt.Sscanf(sampleRate, "%d", &sampleRateInt)
-				if sampleRateInt > 0 {
-					meta.SampleRate = fmt.Sprintf("%.1f kHz", float64(sampleRateInt)/1000.0)
+			codecType, _ := streamMap["codec_type"].(string)
+			if codecType == "video" {
+				if isAttachedPicture(streamMap) {
+					meta.HasEmbeddedArt = true
+				} else {
+					meta.HasVideo = true
 				}
+				continue
 			}
-			if meta.Bitrate == "N/A" {
-				if bitrate, ok := streamMap["bit_rate"].(string); ok {
-					bitrateInt := 0
-					fmt.Sscanf(bitrate, "%d", &bitrateInt)
-					if bitrateInt > 0 {
-						meta.Bitrate = fmt.Sprintf("%d kbps", bitrateInt/1000)
-					}
-				}
+			if codecType != "audio" {
+				continue
 			}
-			if tags, ok := streamMap["tags"].(map[string]interface{}); ok {
-				if title, ok := tags["title"].(string); ok && meta.Title == filepath.Base(filePath) {
-					meta.Title = title
-				}
-				if artist, ok := tags["artist"].(string); ok && meta.Artist == "Unknown Artist" {
-					meta.Artist = artist
-				}
-				if album, ok := tags["album"].(string); ok && meta.Album == "Unknown Album" {
-					meta.Album = album
-				}
+			meta.AudioStreams = append(meta.AudioStreams, parseAudioStream(streamMap))
+			raw, _ := streamMap["tags"].(map[string]interface{})
+			streamTags = append(streamTags, raw)
+		}
+
+		if len(meta.AudioStreams) > 0 {
+			selected := meta.DefaultAudioStream()
+			meta.useStream(selected)
+			// With several audio streams the stream tags describe the
+			// streams ("Commentary", a language), not the track.
+			if len(meta.AudioStreams) == 1 && streamTags[0] != nil {
+				tags.merge(streamTags[0])
 			}
-			break
 		}
 	}
 
+	if meta.Codec == "" {
+		return Metadata{}, errNoAudioStream
+	}
+
+	if chapters, ok := probeData["chapters"].([]interface{}); ok {
+		meta.Chapters = parseChapters(chapters)
+	}
+
+	applyTags(&meta, tags)
+
 	return meta, nil
 }
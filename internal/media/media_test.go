@@ -0,0 +1,54 @@
+package media
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestWalkAudioFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	album := filepath.Join(root, "albums", "Album")
+	if err := os.MkdirAll(album, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"01.mp3", "02.mp3"} {
+		if err := os.WriteFile(filepath.Join(album, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "links"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A loop back up to albums, and two more ways into the same album.
+	loop := filepath.Join(album, "loop")
+	if err := os.Symlink(filepath.Join(root, "albums"), loop); err != nil {
+		t.Skipf("symlinks not available: %v", err)
+	}
+	for _, name := range []string{"first", "second"} {
+		if err := os.Symlink(album, filepath.Join(root, "links", name)); err != nil {
+			t.Skipf("symlinks not available: %v", err)
+		}
+	}
+
+	var found []string
+	var issues []ScanIssue
+	err := walkAudioFiles([]string{root}, Options{FollowSymlinks: true}, extensionSet([]string{".mp3"}),
+		func(path string) { found = append(found, path) },
+		func(path string, err error) { issues = append(issues, ScanIssue{Path: path, Err: err}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(found)
+	want := []string{filepath.Join(album, "01.mp3"), filepath.Join(album, "02.mp3")}
+	if len(found) != len(want) || found[0] != want[0] || found[1] != want[1] {
+		t.Errorf("found %v, want %v", found, want)
+	}
+	if len(issues) != 1 || issues[0].Path != loop || !errors.Is(issues[0].Err, errSymlinkLoop) {
+		t.Errorf("issues = %v, want the loop at %s", issues, loop)
+	}
+}
@@ -0,0 +1,240 @@
+package media
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Override corrects a track without touching the file. Empty fields keep the
+// probed value.
+type Override struct {
+	Title   string `json:"title,omitempty"`
+	Artist  string `json:"artist,omitempty"`
+	Album   string `json:"album,omitempty"`
+	Hidden  bool   `json:"hidden,omitempty"`
+	SortKey string `json:"sortKey,omitempty"`
+}
+
+// IsZero reports whether the override changes nothing.
+func (o Override) IsZero() bool {
+	return o == Override{}
+}
+
+// overridesFile is the on-disk format. Paths are relative to the root
+// holding the track, with forward slashes. Tracks under the first root, which
+// names the library, are in Paths, so a one-folder library's file works on
+// any machine; those under the other roots are in Roots by the root's path.
+// Keys of CUE sheet tracks end in "#" and the track number.
+type overridesFile struct {
+	Version int                            `json:"version"`
+	Paths   map[string]Override            `json:"paths,omitempty"`
+	Roots   map[string]map[string]Override `json:"roots,omitempty"`
+}
+
+const overridesVersion = 1
+
+// Overrides is the set of corrections for one library. It is safe for
+// concurrent use.
+type Overrides struct {
+	mu    sync.RWMutex
+	path  string
+	roots []string
+	paths map[string]Override
+
+	// others holds the entries of the roots after the first, by rootName.
+	others map[string]map[string]Override
+}
+
+// LoadOverrides reads the overrides stored at path for the library made of
+// roots. A missing file yields an empty set that Save will create.
+func LoadOverrides(path string, roots []string) (*Overrides, error) {
+	o := &Overrides{
+		path:   path,
+		roots:  roots,
+		paths:  make(map[string]Override),
+		others: make(map[string]map[string]Override),
+	}
+
+	file, err := readOverridesFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.merge(file)
+	return o, nil
+}
+
+func readOverridesFile(path string) (overridesFile, error) {
+	var file overridesFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return file, nil
+}
+
+func (o *Overrides) merge(file overridesFile) int {
+	count := 0
+	for k, v := range file.Paths {
+		o.paths[k] = v
+		count++
+	}
+	for root, entries := range file.Roots {
+		if o.others[root] == nil {
+			o.others[root] = make(map[string]Override)
+		}
+		for k, v := range entries {
+			o.others[root][k] = v
+			count++
+		}
+	}
+	return count
+}
+
+// rootName is how a root other than the first is named in the file.
+func rootName(root string) string {
+	return filepath.ToSlash(filepath.Clean(root))
+}
+
+// entries returns the map holding a file's entry and its key there: the
+// file's path relative to the innermost root holding it. A file outside
+// every root is keyed by its full path among the first root's entries.
+// With create, a missing map for the root is added.
+func (o *Overrides) entries(filePath string, create bool) (map[string]Override, string) {
+	key, owner := RelPath(o.roots, filePath)
+	if owner <= 0 {
+		return o.paths, filepath.ToSlash(key)
+	}
+
+	name := rootName(o.roots[owner])
+	m := o.others[name]
+	if m == nil && create {
+		m = make(map[string]Override)
+		o.others[name] = m
+	}
+	return m, filepath.ToSlash(key)
+}
+
+// songKey returns the suffix that tells tracks of a CUE sheet apart from
+// the file they share.
+func songKey(meta Metadata) string {
+	if !meta.IsCueTrack() {
+		return ""
+	}
+	return fmt.Sprintf("#%d", meta.CueTrack)
+}
+
+// Lookup returns the override for a song.
+func (o *Overrides) Lookup(meta Metadata) (Override, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	entries, key := o.entries(meta.FilePath, false)
+	ov, ok := entries[key+songKey(meta)]
+	return ov, ok
+}
+
+// Set stores the override for a song and saves the set. A zero override
+// removes the entry.
+func (o *Overrides) Set(meta Metadata, ov Override) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, key := o.entries(meta.FilePath, true)
+	key += songKey(meta)
+	if ov.IsZero() {
+		delete(entries, key)
+	} else {
+		entries[key] = ov
+	}
+	return o.saveLocked(o.path)
+}
+
+// Export writes the overrides to w in the on-disk format.
+func (o *Overrides) Export(w io.Writer) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.encode(w)
+}
+
+// Import merges the overrides from another file, replacing entries with the
+// same key, saves the result and returns the number of entries imported.
+func (o *Overrides) Import(path string) (int, error) {
+	file, err := readOverridesFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	count := o.merge(file)
+	return count, o.saveLocked(o.path)
+}
+
+func (o *Overrides) encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	file := overridesFile{Version: overridesVersion, Paths: o.paths}
+	for root, entries := range o.others {
+		if len(entries) == 0 {
+			continue
+		}
+		if file.Roots == nil {
+			file.Roots = make(map[string]map[string]Override)
+		}
+		file.Roots[root] = entries
+	}
+	return enc.Encode(file)
+}
+
+// saveLocked writes the set atomically through a temporary file.
+func (o *Overrides) saveLocked(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".overrides-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := o.encode(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// apply overlays the override for meta's song, if any.
+func (o *Overrides) apply(meta *Metadata) {
+	ov, ok := o.Lookup(*meta)
+	if !ok {
+		return
+	}
+	if ov.Title != "" {
+		meta.Title = ov.Title
+	}
+	if ov.Artist != "" {
+		meta.Artist = ov.Artist
+	}
+	if ov.Album != "" {
+		meta.Album = ov.Album
+	}
+	meta.Hidden = ov.Hidden
+	meta.SortKey = ov.SortKey
+	meta.Overridden = true
+}
@@ -0,0 +1,54 @@
+package media
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOverridesSeparateRoots(t *testing.T) {
+	dir := t.TempDir()
+	rootA, rootB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	nested := filepath.Join(rootA, "nested")
+	roots := []string{rootA, rootB, nested}
+	path := filepath.Join(dir, "overrides.json")
+
+	o, err := LoadOverrides(path, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inA := Metadata{FilePath: filepath.Join(rootA, "Album", "01.flac")}
+	inB := Metadata{FilePath: filepath.Join(rootB, "Album", "01.flac")}
+	inNested := Metadata{FilePath: filepath.Join(nested, "Album", "01.flac")}
+	if err := o.Set(inB, Override{Hidden: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Set(inA, Override{Album: "Fixed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reloaded from disk, each copy keeps its own entry.
+	o, err = LoadOverrides(path, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ov, ok := o.Lookup(inA); !ok || ov.Hidden || ov.Album != "Fixed" {
+		t.Errorf("root A: got %+v, %v", ov, ok)
+	}
+	if ov, ok := o.Lookup(inB); !ok || !ov.Hidden || ov.Album != "" {
+		t.Errorf("root B: got %+v, %v", ov, ok)
+	}
+	if ov, ok := o.Lookup(inNested); ok {
+		t.Errorf("nested root: got %+v, want no entry", ov)
+	}
+
+	// Removing one copy's entry leaves the other.
+	if err := o.Set(inB, Override{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.Lookup(inB); ok {
+		t.Error("root B entry still there after removal")
+	}
+	if _, ok := o.Lookup(inA); !ok {
+		t.Error("root A entry lost")
+	}
+}
@@ -0,0 +1,135 @@
+package media
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tagSet holds probe tags keyed by a normalized name so lookups ignore case
+// and separator differences between containers ("TITLE", "title",
+// "album_artist", "Album Artist", "MusicBrainz Album Id", ...).
+type tagSet map[string]string
+
+func normalizeTagKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(key)
+}
+
+// merge adds tags from a raw ffprobe tag object without overwriting
+// values that are already present.
+func (t tagSet) merge(raw map[string]interface{}) {
+	for k, v := range raw {
+		value, ok := v.(string)
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		key := normalizeTagKey(k)
+		if _, exists := t[key]; !exists {
+			t[key] = value
+		}
+	}
+}
+
+// get returns the first non-empty value among the given tag names.
+func (t tagSet) get(keys ...string) string {
+	for _, k := range keys {
+		if value, ok := t[normalizeTagKey(k)]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// lyrics returns the embedded lyrics. ffprobe names ID3 USLT frames after
+// their language and description, e.g. "lyrics-eng", so any tag starting
+// with "lyrics" counts; the plain names win.
+func (t tagSet) lyrics() string {
+	if text := t.get("lyrics", "unsyncedlyrics", "unsynced lyrics"); text != "" {
+		return text
+	}
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		if strings.HasPrefix(key, "lyrics") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		return t[keys[0]]
+	}
+	return ""
+}
+
+// parseNumberPair parses values like "3" or "3/12".
+func parseNumberPair(value string) (n, total int) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, 0
+	}
+	if i := strings.Index(value, "/"); i >= 0 {
+		fmt.Sscanf(strings.TrimSpace(value[:i]), "%d", &n)
+		fmt.Sscanf(strings.TrimSpace(value[i+1:]), "%d", &total)
+		return n, total
+	}
+	fmt.Sscanf(value, "%d", &n)
+	return n, 0
+}
+
+// parseYear extracts the leading four-digit year from a date tag such as
+// "2019", "2019-04-12" or "2019/04".
+func parseYear(date string) int {
+	date = strings.TrimSpace(date)
+	if len(date) < 4 {
+		return 0
+	}
+	year := 0
+	if _, err := fmt.Sscanf(date[:4], "%d", &year); err != nil {
+		return 0
+	}
+	return year
+}
+
+func applyTags(meta *Metadata, tags tagSet) {
+	if title := tags.get("title"); title != "" {
+		meta.Title = title
+	}
+	if artist := tags.get("artist"); artist != "" {
+		meta.Artist = artist
+	}
+	if album := tags.get("album"); album != "" {
+		meta.Album = album
+	}
+
+	meta.AlbumArtist = tags.get("album_artist", "albumartist", "album artist")
+	meta.Composer = tags.get("composer")
+	meta.Genre = tags.get("genre")
+	meta.Comment = tags.get("comment", "description")
+
+	meta.TrackNumber, meta.TrackTotal = parseNumberPair(tags.get("track", "tracknumber"))
+	if meta.TrackTotal == 0 {
+		fmt.Sscanf(tags.get("tracktotal", "totaltracks"), "%d", &meta.TrackTotal)
+	}
+	meta.DiscNumber, meta.DiscTotal = parseNumberPair(tags.get("disc", "discnumber"))
+	if meta.DiscTotal == 0 {
+		fmt.Sscanf(tags.get("disctotal", "totaldiscs"), "%d", &meta.DiscTotal)
+	}
+
+	meta.Lyrics = tags.lyrics()
+
+	meta.Date = tags.get("date", "year", "originaldate")
+	meta.Year = parseYear(meta.Date)
+
+	meta.MusicBrainz = MusicBrainzIDs{
+		RecordingID:    tags.get("musicbrainz_trackid", "musicbrainz track id"),
+		ReleaseTrackID: tags.get("musicbrainz_releasetrackid", "musicbrainz release track id"),
+		AlbumID:        tags.get("musicbrainz_albumid", "musicbrainz album id"),
+		ArtistID:       tags.get("musicbrainz_artistid", "musicbrainz artist id"),
+		AlbumArtistID:  tags.get("musicbrainz_albumartistid", "musicbrainz album artist id"),
+		ReleaseGroupID: tags.get("musicbrainz_releasegroupid", "musicbrainz release group id"),
+	}
+}
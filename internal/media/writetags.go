@@ -0,0 +1,121 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"Player/internal/archive"
+)
+
+// Tag keys accepted by WriteTags, named as ffmpeg's -metadata expects them.
+const (
+	TagTitle       = "title"
+	TagArtist      = "artist"
+	TagAlbum       = "album"
+	TagAlbumArtist = "album_artist"
+	TagTrack       = "track"
+	TagDate        = "date"
+	TagGenre       = "genre"
+)
+
+// WriteTags rewrites the tags of an audio file in place. ffmpeg stream-copies
+// the file into a temporary sibling with the new metadata, which is then
+// renamed over the original, so a failed write never leaves a truncated file.
+// An empty value removes the tag.
+func WriteTags(filePath string, tags map[string]string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filePath)
+	ext := filepath.Ext(filePath)
+	tmp, err := os.CreateTemp(dir, ".stelle-tag-*"+ext)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	args := []string{"-v", "error", "-y", "-i", filePath, "-map", "0", "-c", "copy", "-map_metadata", "0"}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Ogg muxers prefer the audio stream's own comments over global ones, so
+	// the stream-level tags are rewritten as well.
+	streamTags := strings.EqualFold(ext, ".ogg") || strings.EqualFold(ext, ".opus")
+	for _, k := range keys {
+		args = append(args, "-metadata", k+"="+tags[k])
+		if streamTags {
+			args = append(args, "-metadata:s:a:0", k+"="+tags[k])
+		}
+	}
+	args = append(args, tmpPath)
+
+	cmd := exec.Command("ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("replace %s: %w", filepath.Base(filePath), err)
+	}
+	return nil
+}
+
+// Probe reads the metadata of a single file, e.g. after its tags changed.
+func Probe(filePath string, opts Options) (Metadata, error) {
+	return extractMetadata(filePath, opts)
+}
+
+// Reprobe reads a song's metadata again. Tracks of a CUE sheet are rebuilt
+// from their sheet.
+func Reprobe(meta Metadata, opts Options) (Metadata, error) {
+	if meta.InArchive() {
+		r, err := archive.Open(meta.Archive)
+		if err != nil {
+			return Metadata{}, err
+		}
+		defer r.Close()
+		return extractEntryMetadata(r, meta.Archive, meta.ArchiveEntry, opts)
+	}
+	if !meta.IsCueTrack() {
+		return Probe(meta.FilePath, opts)
+	}
+
+	sheet, err := LoadCueSheet(meta.CueSheet)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, f := range sheet.Files {
+		audio, err := sheet.resolveFile(f.Name, extensionSet(opts.Extensions))
+		if err != nil || audio != meta.FilePath {
+			continue
+		}
+		tracks, err := loadCueTracks(sheet, f, audio, opts)
+		if err != nil {
+			return Metadata{}, err
+		}
+		for _, track := range tracks {
+			if track.CueTrack == meta.CueTrack {
+				return track, nil
+			}
+		}
+	}
+	return Metadata{}, fmt.Errorf("track %d is no longer in %s", meta.CueTrack, filepath.Base(meta.CueSheet))
+}
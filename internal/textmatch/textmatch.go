@@ -0,0 +1,88 @@
+// textmatch/textmatch.go
+// Loose comparison of tags written by different taggers and services.
+//
+// Functions:
+//   - Normalize: reduces a title or name to what copies of a track agree on
+
+package textmatch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// featuringMarkers start the guest credits that taggers put in titles and
+// artist names inconsistently. A bare "feat" is also a word, as in "A Feat
+// of Strength", so it only counts inside brackets.
+var featuringMarkers = []string{" feat. ", " ft. ", " featuring "}
+
+// Normalize reduces a tag to what copies of a track agree on:
+// lower case, no guest credits, no "(Remastered 2011)" style suffixes, no
+// punctuation and single spaces.
+func Normalize(s string) string {
+	s = " " + strings.ToLower(s) + " "
+	s = stripBracketed(s, func(inner string) bool {
+		return strings.Contains(inner, "remaster") || isCredit(inner)
+	})
+	for _, marker := range featuringMarkers {
+		if i := strings.Index(s, marker); i > 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.ReplaceAll(s, "&", " and ")
+
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+			// "Don't" and "Dont" are the same title.
+		default:
+			space = true
+		}
+	}
+	return strings.TrimPrefix(b.String(), "the ")
+}
+
+// isCredit reports whether the inside of a bracket is a guest credit,
+// "feat. Name", "ft Name" or "featuring Name".
+func isCredit(inner string) bool {
+	for _, marker := range []string{"feat", "ft", "featuring"} {
+		if rest, ok := strings.CutPrefix(inner, marker); ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripBracketed removes the (...) and [...] groups whose lower-case
+// contents satisfy drop.
+func stripBracketed(s string, drop func(inner string) bool) string {
+	for _, pair := range []string{"()", "[]"} {
+		for start := 0; ; {
+			open := strings.IndexByte(s[start:], pair[0])
+			if open < 0 {
+				break
+			}
+			open += start
+			end := strings.IndexByte(s[open:], pair[1])
+			if end < 0 {
+				break
+			}
+			end += open
+			if drop(strings.TrimSpace(s[open+1 : end])) {
+				s = s[:open] + " " + s[end+1:]
+				start = open
+			} else {
+				start = end + 1
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,31 @@
+package textmatch
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"The Song", "song"},
+		{"Don't Stop", "dont stop"},
+		{"Song (Remastered 2011)", "song"},
+		{"Song [2009 Remaster]", "song"},
+		{"Song (feat. Guest)", "song"},
+		{"Song [ft Guest]", "song"},
+		{"Song feat. Guest", "song"},
+		{"Song ft. Guest", "song"},
+		{"Artist featuring Guest", "artist"},
+		{"Salt & Pepper", "salt and pepper"},
+		{"  Spaced   Out!  ", "spaced out"},
+		// "feat" as a word, not a credit.
+		{"A Feat of Strength", "a feat of strength"},
+		{"Feat of Clay", "feat of clay"},
+		{"Song (Feats of Strength)", "song feats of strength"},
+		{"Song (Live)", "song live"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"strings"
 
 	"Player/internal/app"
+	"Player/internal/config"
 	ffmpeginstall "Player/internal/ffmpeg_install"
+	"Player/internal/lyrics"
 	"Player/service"
 	"path/filepath"
 )
@@ -17,17 +19,34 @@ import (
 var Version = "dev"
 
 func main() {
-	sourceDir := flag.String("sd", "", "Source directory to load music files")
+	var sourceDirs stringList
+	flag.Var(&sourceDirs, "sd", "Source directory to load music files (repeatable)")
 	installFFmpegFlag := flag.Bool("install-ffmpeg", false, "Force FFmpeg installation prompt")
 	customFFmpegDir := flag.String("use-custom-ffmpeg", "", "Path to directory containing custom FFmpeg binaries")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
+	configPath := flag.String("config", "", "Path to the config file (default: user config directory)")
+	previewFlag := flag.Bool("preview-patterns", false, "Print what the path patterns infer for each file and exit")
+	exportOverrides := flag.String("export-overrides", "", "Write the library's metadata overrides to a file and exit")
+	importOverrides := flag.String("import-overrides", "", "Merge metadata overrides from a file into the library and exit")
+	duplicatesFlag := flag.Bool("duplicates", false, "Print groups of duplicate tracks with their quality and exit")
+	duplicatesAudio := flag.Bool("duplicates-audio", false, "With -duplicates, also compare decoded audio (slow)")
+	var patternFlags stringList
+	flag.Var(&patternFlags, "pattern", "Path pattern to preview, e.g. \"{artist}/{album}/{track} - {title}\" (repeatable)")
 	flag.Parse()
 
+	lyrics.UserAgent = "StellePlayer/" + Version
+
 	if *versionFlag {
 		fmt.Printf("StellePlayer version %s\n", Version)
 		os.Exit(0)
 	}
 
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Prepend custom FFmpeg directory to PATH if provided
 	if *customFFmpegDir != "" {
 		absDir, err := filepath.Abs(*customFFmpegDir)
@@ -48,31 +67,138 @@ func main() {
 	}
 
 	// Check for FFmpeg dependencies
-	if !isFFmpegInstalled() {
+	offline := *previewFlag || *exportOverrides != "" || *importOverrides != ""
+	if !offline && !isFFmpegInstalled() {
 		handleFFmpegMissing()
 	}
 
-	musicDir := strings.TrimSpace(*sourceDir)
-	if musicDir == "" {
+	roots := libraryRoots(sourceDirs, cfg.LibraryRoots)
+	if len(roots) == 0 {
 		fmt.Println("No -sd flag provided. Please select a folder containing your music files.")
 		selectedDir, err := service.PickFolder()
 		if err != nil {
 			fmt.Printf("Failed to pick folder: %v\n", err)
 			os.Exit(1)
 		}
-		musicDir = strings.TrimSpace(selectedDir)
-		if musicDir == "" {
+		selectedDir = strings.TrimSpace(selectedDir)
+		if selectedDir == "" {
 			fmt.Println("No folder selected. Exiting.")
 			os.Exit(1)
 		}
+		if abs, err := filepath.Abs(selectedDir); err == nil {
+			selectedDir = abs
+		}
+		roots = append(roots, selectedDir)
+	}
+
+	if *exportOverrides != "" || *importOverrides != "" {
+		if err := transferOverrides(roots, *exportOverrides, *importOverrides); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	if err := app.Run(musicDir); err != nil {
+	if *previewFlag {
+		patterns := []string(patternFlags)
+		if len(patterns) == 0 {
+			patterns = cfg.PathPatterns
+		}
+		if err := previewPatterns(roots, patterns, cfg); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *duplicatesFlag {
+		if err := app.ReportDuplicates(os.Stdout, roots, cfg, *duplicatesAudio); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := app.Run(roots, cfg); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+// libraryRoots combines the -sd directories with the configured roots,
+// dropping blanks and duplicates while keeping the order. Roots are made
+// absolute, so song IDs and the positions keyed by them don't depend on
+// the working directory.
+func libraryRoots(flagDirs, configDirs []string) []string {
+	var roots []string
+	seen := make(map[string]bool)
+	for _, dir := range append(append([]string{}, flagDirs...), configDirs...) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		key := filepath.Clean(dir)
+		if abs, err := filepath.Abs(dir); err == nil {
+			key = abs
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		roots = append(roots, key)
+	}
+	return roots
+}
+
+// loadConfig reads the config from path, or from the default location when
+// path is empty.
+func loadConfig(path string) (config.Config, error) {
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return config.Config{}, nil
+		}
+		path = defaultPath
+	}
+	return config.Load(path)
+}
+
+// transferOverrides imports and/or exports the library's metadata overrides
+// so corrections can travel with the library.
+func transferOverrides(roots []string, exportPath, importPath string) error {
+	overrides, err := app.LoadOverrides(roots)
+	if err != nil {
+		return err
+	}
+	if overrides == nil {
+		return fmt.Errorf("metadata overrides need a user config directory")
+	}
+
+	if importPath != "" {
+		count, err := overrides.Import(importPath)
+		if err != nil {
+			return fmt.Errorf("import overrides: %w", err)
+		}
+		fmt.Printf("Imported %d override(s) from %s\n", count, importPath)
+	}
+
+	if exportPath != "" {
+		f, err := os.Create(exportPath)
+		if err != nil {
+			return err
+		}
+		if err := overrides.Export(f); err != nil {
+			f.Close()
+			return fmt.Errorf("export overrides: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Exported overrides to %s\n", exportPath)
+	}
+	return nil
+}
+
 // isFFmpegInstalled checks if ffplay and ffprobe are available in PATH.
 func isFFmpegInstalled() bool {
 	_, errPlay := exec.LookPath("ffplay")
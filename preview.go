@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"Player/internal/config"
+	"Player/internal/media"
+)
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ", ") }
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// previewFieldOrder fixes the order fields are printed in.
+var previewFieldOrder = []string{"albumartist", "artist", "album", "disc", "track", "year", "genre", "title"}
+
+// previewPatterns prints what each path pattern infers for every audio file
+// under the library roots, so patterns can be checked before enabling
+// inference.
+func previewPatterns(roots []string, rawPatterns []string, cfg config.Config) error {
+	if len(rawPatterns) == 0 {
+		return fmt.Errorf("no path patterns to preview: pass -pattern or set pathPatterns in the config")
+	}
+
+	patterns, err := media.CompilePatterns(rawPatterns)
+	if err != nil {
+		return err
+	}
+
+	opts := media.Options{Exclude: cfg.Exclude, FollowSymlinks: cfg.FollowSymlinks, ScanArchives: cfg.ScanArchives}
+	if len(cfg.Extensions) > 0 {
+		opts.Extensions = cfg.Extensions
+	}
+	files, err := media.FindAudioFiles(roots, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pattern preview for %s (%d files)\n\nPatterns:\n", strings.Join(roots, ", "), len(files))
+	for i, p := range patterns {
+		fmt.Printf("  [%d] %s\n", i+1, p)
+	}
+	fmt.Println()
+
+	matched := make([]int, len(patterns))
+	unmatched := 0
+	for _, file := range files {
+		rel, _ := media.RelPath(roots, file)
+		fmt.Println(rel)
+
+		hit := false
+		for i, p := range patterns {
+			fields, ok := p.Match(rel)
+			if !ok {
+				fmt.Printf("  [%d] no match\n", i+1)
+				continue
+			}
+			hit = true
+			matched[i]++
+
+			var parts []string
+			for _, name := range previewFieldOrder {
+				if value, ok := fields[name]; ok {
+					parts = append(parts, fmt.Sprintf("%s=%q", name, value))
+				}
+			}
+			fmt.Printf("  [%d] %s\n", i+1, strings.Join(parts, " "))
+		}
+		if !hit {
+			unmatched++
+		}
+	}
+
+	fmt.Println("\nSummary:")
+	for i := range patterns {
+		fmt.Printf("  [%d] matched %d/%d\n", i+1, matched[i], len(files))
+	}
+	fmt.Printf("  matched by no pattern: %d\n", unmatched)
+	return nil
+}